@@ -1,25 +1,49 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/johannessarpola/lutakkols/cmd/constants"
+	"github.com/johannessarpola/lutakkols/cmd/diff"
+	"github.com/johannessarpola/lutakkols/cmd/export"
+	"github.com/johannessarpola/lutakkols/cmd/fixtures"
+	"github.com/johannessarpola/lutakkols/cmd/list"
+	"github.com/johannessarpola/lutakkols/cmd/migrate"
+	"github.com/johannessarpola/lutakkols/cmd/providers"
+	"github.com/johannessarpola/lutakkols/cmd/selectors"
+	"github.com/johannessarpola/lutakkols/cmd/serve"
+	"github.com/johannessarpola/lutakkols/cmd/show"
+	"github.com/johannessarpola/lutakkols/cmd/site"
+	"github.com/johannessarpola/lutakkols/cmd/store"
 	"github.com/johannessarpola/lutakkols/cmd/sync"
+	"github.com/johannessarpola/lutakkols/internal/state"
 	"github.com/johannessarpola/lutakkols/internal/views"
 	"github.com/johannessarpola/lutakkols/pkg/api/options"
 	"github.com/johannessarpola/lutakkols/pkg/api/provider"
+	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/httpcache"
+	fetchselectors "github.com/johannessarpola/lutakkols/pkg/fetch/selectors"
+	"github.com/johannessarpola/lutakkols/pkg/sources"
 	"github.com/spf13/cobra"
 	v "github.com/spf13/viper"
+	"net/http"
 	"os"
-	"path"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 type config struct {
-	Address  string
-	Offline  bool
-	InputDir string
-	LogFile  string
-	Verbose  bool
+	Address   string
+	Sources   []string
+	Offline   bool
+	Store     bool
+	Hybrid    bool
+	InputDir  string
+	LogFile   string
+	StateFile string
+	ExportDir string
+	Verbose   bool
 }
 
 var Config config
@@ -29,83 +53,176 @@ var rootCmd = &cobra.Command{
 	Short: "View Lutakko gigs with CLI",
 	Long:  "View Lutakko gigs with CLI",
 	// For children
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Bind the verbose flag to viper
 		err := v.BindPFlag("verbose", cmd.Flags().Lookup("verbose"))
 		if err != nil {
 			fmt.Printf("could not bind flag: %v\n", err)
 		}
+		if err = loadSelectors(v.GetString("selectors")); err != nil {
+			return err
+		}
+		setupHTTPCache()
+		setupRetries()
+		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
-		if v.GetBool("offline") {
-			offlineCli(Config.InputDir)
+		if v.GetBool("store") {
+			runCli(options.UseStore, "", Config.InputDir)
+		} else if v.GetBool("offline") {
+			runCli(options.UseOffline, "", Config.InputDir)
+		} else if v.GetBool("hybrid") {
+			runCli(options.UseHybrid, v.GetString("address"), Config.InputDir)
 		} else {
-			onlineCli("https://www.jelmu.net")
+			runCli(options.UseOnline, v.GetString("address"), "")
 		}
 	},
 }
 
-func setupTMUI(p provider.Provider) {
-
-	m := views.NewEventsList(p)
-	prog := tea.NewProgram(m, tea.WithAltScreen())
-
-	if _, err := prog.Run(); err != nil {
-		fmt.Println("err running program:", err)
-		os.Exit(1)
+// loadSelectors replaces the embedded selector profile if one is given, an invalid profile stops the startup
+func loadSelectors(path string) error {
+	if len(path) == 0 {
+		return nil
 	}
+	p, err := fetchselectors.Load(path)
+	if err != nil {
+		return fmt.Errorf("could not load selectors: %w", err)
+	}
+	fetchselectors.Use(p)
+	return nil
 }
 
-func onlineCli(path string) {
-	c := provider.Config{
-		EventsSourceURL: path,
+// setupHTTPCache makes the fetches go through the on-disk HTTP cache if it is enabled
+func setupHTTPCache() {
+	if !v.GetBool("http_cache") {
+		return
+	}
+	dir := v.GetString("http_cache_dir")
+	if len(dir) == 0 {
+		dir = defaultHTTPCacheDir()
 	}
+	maxBytes := v.GetInt64("http_cache_size") * 1024 * 1024
+	fetch.SetTransport(httpcache.New(dir, maxBytes, v.GetDuration("http_cache_ttl"), http.DefaultTransport))
+}
 
-	p, err := provider.New(&c, options.UseOnline)
+// setupRetries configures how many times transient fetch failures are retried
+func setupRetries() {
+	p := fetch.DefaultRetryPolicy
+	p.Retries = max(v.GetInt("retries"), 0)
+	p.BaseDelay = v.GetDuration("retry_delay")
+	p.MaxRetryAfter = v.GetDuration("max_retry_after")
+	fetch.SetRetryPolicy(p)
+}
+
+func defaultHTTPCacheDir() string {
+	base, err := os.UserCacheDir()
 	if err != nil {
-		panic(err)
+		base = os.TempDir()
 	}
-	setupTMUI(p)
+	return filepath.Join(base, "lutakkols", "http")
 }
 
-func offlineCli(inputDir string) {
-	ep := path.Join(inputDir, constants.EventsFile)
-	edp := path.Join(inputDir, constants.EventsDetailsFile)
+func setupTMUI(p provider.Provider) {
+
+	// cancels the pending fetches once the program quits
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := v.GetString("state_file")
+	if len(path) == 0 {
+		path = state.DefaultPath()
+	}
+	st, err := state.Load(path)
+	if err != nil {
+		fmt.Println("err loading state:", err)
+		os.Exit(1)
+	}
 
-	config := provider.Config{
-		EventSourceFsPath:  ep,
-		EventDetailsFsPath: edp,
-		AsciiGen:           views.GenerateOfflineAscii,
+	exportDir := v.GetString("export_dir")
+	if len(exportDir) == 0 {
+		exportDir = filepath.Dir(path)
 	}
+	m := views.NewEventsList(ctx, p, st).WithExportDir(exportDir)
+	prog := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
 
-	p, err := provider.New(&config, options.UseOffline)
+	if _, err := prog.Run(); err != nil {
+		fmt.Println("err running program:", err)
+		os.Exit(1)
+	}
+}
+
+// runCli runs the UI with the provider of the kind, the data is read from inputDir when not online
+func runCli(kind options.TypeOption, address string, inputDir string) {
+	p, err := providers.New(providers.Config{
+		Kind:     kind,
+		Address:  address,
+		Sources:  v.GetStringSlice("sources"),
+		InputDir: inputDir,
+	})
 	if err != nil {
 		panic(err)
 	}
 	setupTMUI(p)
-
 }
 
 func init() {
 	rootCmd.AddCommand(sync.Cmd)
+	rootCmd.AddCommand(migrate.Cmd)
+	rootCmd.AddCommand(store.Cmd)
+	rootCmd.AddCommand(fixtures.Cmd)
+	rootCmd.AddCommand(selectors.Cmd)
+	rootCmd.AddCommand(diff.Cmd)
+	rootCmd.AddCommand(serve.Cmd)
+	rootCmd.AddCommand(export.Cmd)
+	rootCmd.AddCommand(list.Cmd)
+	rootCmd.AddCommand(show.Cmd)
+	rootCmd.AddCommand(site.Cmd)
 
-	rootCmd.Flags().StringVarP(&Config.Address, "address", "a", "https://www.jelmu.net", "Server address")
+	rootCmd.Flags().StringVarP(&Config.Address, "address", "a", sources.JelmuURL, "Server address, used when no sources are given")
+	rootCmd.Flags().StringSliceVar(&Config.Sources, "sources", nil, "Sources to aggregate as name or name=url, available: "+strings.Join(sources.Names(), ", "))
 	rootCmd.Flags().BoolVarP(&Config.Offline, "offline", "o", false, "Run in offline mode")
+	rootCmd.Flags().BoolVarP(&Config.Store, "store", "s", false, "Run from the SQLite store in input_dir")
+	rootCmd.Flags().BoolVarP(&Config.Hybrid, "hybrid", "y", false, "Run online and fall back to the offline data in input_dir when fetches fail")
 	rootCmd.Flags().StringVarP(&Config.InputDir, "input_dir", "i", ".data", "Directory to use with offline mode")
 	rootCmd.Flags().StringVarP(&Config.LogFile, "logfile", "l", "debug.log", "File to write log into")
+	rootCmd.Flags().StringVar(&Config.StateFile, "state_file", "", "File to keep the favourites in, defaults to the user config directory")
+	rootCmd.Flags().StringVar(&Config.ExportDir, "export_dir", "", "Directory the events are exported into from the event view, defaults to the directory of the state file")
 	// Inherited for all
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Verbose")
+	rootCmd.PersistentFlags().Bool("http_cache", false, "Cache the fetched pages and images on disk")
+	rootCmd.PersistentFlags().String("http_cache_dir", "", "Directory of the HTTP cache, defaults to the user cache directory")
+	rootCmd.PersistentFlags().Int64("http_cache_size", 100, "Size limit of the HTTP cache in megabytes, 0 for no limit")
+	rootCmd.PersistentFlags().Duration("http_cache_ttl", 5*time.Minute, "How long responses without caching headers are used without revalidating")
+	rootCmd.PersistentFlags().Int("retries", fetch.DefaultRetryPolicy.Retries, "How many times to retry fetches which failed temporarily")
+	rootCmd.PersistentFlags().Duration("retry_delay", fetch.DefaultRetryPolicy.BaseDelay, "Delay before the first retry, doubled for each retry")
+	rootCmd.PersistentFlags().Duration("max_retry_after", fetch.DefaultRetryPolicy.MaxRetryAfter, "Longest Retry-After of the site which is waited for, longer ones fail the fetch")
+	rootCmd.PersistentFlags().String("selectors", "", "Selector profile (YAML or JSON) to use instead of the embedded one")
 
 	err := v.BindPFlag("address", rootCmd.Flags().Lookup("address"))
 	if err != nil {
 		fmt.Printf("could not bind flag: %v\n", err)
 	}
+	err = v.BindPFlag("sources", rootCmd.Flags().Lookup("sources"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
 	err = v.BindPFlag("offline", rootCmd.Flags().Lookup("offline"))
 	if err != nil {
 		fmt.Printf("could not bind flag: %v\n", err)
 	}
 
+	err = v.BindPFlag("store", rootCmd.Flags().Lookup("store"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("hybrid", rootCmd.Flags().Lookup("hybrid"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
 	err = v.BindPFlag("logfile", rootCmd.Flags().Lookup("logfile"))
 	if err != nil {
 		fmt.Printf("could not bind flag: %v\n", err)
@@ -116,6 +233,27 @@ func init() {
 		fmt.Printf("could not bind flag: %v\n", err)
 	}
 
+	err = v.BindPFlag("state_file", rootCmd.Flags().Lookup("state_file"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+	err = v.BindPFlag("export_dir", rootCmd.Flags().Lookup("export_dir"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	for _, f := range []string{"http_cache", "http_cache_dir", "http_cache_size", "http_cache_ttl", "retries", "retry_delay", "max_retry_after"} {
+		err = v.BindPFlag(f, rootCmd.PersistentFlags().Lookup(f))
+		if err != nil {
+			fmt.Printf("could not bind flag: %v\n", err)
+		}
+	}
+
+	err = v.BindPFlag("selectors", rootCmd.PersistentFlags().Lookup("selectors"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
 }
 
 // Execute executes the root command.
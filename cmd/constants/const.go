@@ -2,3 +2,5 @@ package constants
 
 const EventsFile = "events.json"
 const EventsDetailsFile = "event_details.json"
+const EventsAsciiFile = "event_ascii.json"
+const StoreFile = "lutakkols.db"
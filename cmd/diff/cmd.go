@@ -0,0 +1,83 @@
+package diff
+
+import (
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/constants"
+	"github.com/johannessarpola/lutakkols/pkg/diff"
+	"github.com/johannessarpola/lutakkols/pkg/snapshot"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"os"
+	"path"
+)
+
+type RunConfig struct {
+	BeforeDir string
+	AfterDir  string
+	Format    string
+}
+
+// Paths returns the snapshot files in the directory
+func Paths(dir string) snapshot.Paths {
+	return snapshot.Paths{
+		Events:  path.Join(dir, constants.EventsFile),
+		Details: path.Join(dir, constants.EventsDetailsFile),
+	}
+}
+
+// Run compares the snapshots in the directories and prints the report
+func Run(conf RunConfig) error {
+	format, err := diff.ParseFormat(conf.Format)
+	if err != nil {
+		return err
+	}
+
+	before, err := load(conf.BeforeDir)
+	if err != nil {
+		return err
+	}
+	after, err := load(conf.AfterDir)
+	if err != nil {
+		return err
+	}
+
+	return diff.Compare(before, after).Write(os.Stdout, format)
+}
+
+// load loads the snapshot in the directory, unlike snapshot.Load a missing events file is an error as a mistyped
+// directory would otherwise report every event as new or removed
+func load(dir string) (snapshot.Snapshot, error) {
+	p := Paths(dir)
+	if _, err := os.Stat(p.Events); err != nil {
+		return snapshot.Snapshot{}, fmt.Errorf("no synced events in %s: %w", dir, err)
+	}
+	s, err := snapshot.Load(p)
+	if err != nil {
+		return s, fmt.Errorf("could not load %s: %w", dir, err)
+	}
+	return s, nil
+}
+
+var Cmd = &cobra.Command{
+	Use:   "diff <before_dir> <after_dir>",
+	Short: "Reports changes between two syncs",
+	Long:  "Compares the data synced into two directories and reports new, removed, cancelled and sold out gigs, price changes and changed play times",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := RunConfig{
+			BeforeDir: args[0],
+			AfterDir:  args[1],
+			Format:    v.GetString("diff_format"),
+		}
+		return Run(c)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringP("format", "f", string(diff.Text), "Output format: text, json or markdown")
+
+	err := v.BindPFlag("diff_format", Cmd.Flags().Lookup("format"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Package exitcode has the exit codes of the commands so that scripts can tell the failures apart
+package exitcode
+
+import "errors"
+
+const (
+	// Failure is any error without a more specific code
+	Failure = 1
+	// Usage is an invalid flag or argument
+	Usage = 2
+	// NoMatch is when no events matched the filters
+	NoMatch = 3
+	// NotFound is when the requested event does not exist
+	NotFound = 4
+)
+
+// Error exits with the code
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// New wraps the error to exit with the code
+func New(code int, err error) error {
+	return Error{Code: code, Err: err}
+}
+
+// Code returns the code to exit with after the error, 0 when there is no error
+func Code(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return Failure
+}
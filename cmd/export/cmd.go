@@ -0,0 +1,171 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/providers"
+	"github.com/johannessarpola/lutakkols/pkg/api/provider"
+	"github.com/johannessarpola/lutakkols/pkg/export"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"io"
+	"os"
+	"time"
+)
+
+type RunConfig struct {
+	Provider providers.Config
+	Output   string
+	Timeout  time.Duration
+	Verbose  bool
+}
+
+// writeFn renders the events from the provider into the writer
+type writeFn func(ctx context.Context, p provider.Provider, w io.Writer) error
+
+// Run writes the events of the configured provider into the output, "-" is the stdout
+func Run(conf RunConfig, write writeFn) error {
+	if conf.Verbose {
+		logger.SetLogger(&logger.StdOutLogger{})
+	}
+
+	p, err := providers.New(conf.Provider)
+	if err != nil {
+		return fmt.Errorf("could not create provider: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+
+	if conf.Output == "-" {
+		return write(ctx, p, os.Stdout)
+	}
+	err = export.WriteFile(conf.Output, func(w io.Writer) error {
+		return write(ctx, p, w)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", conf.Output)
+	return nil
+}
+
+// writeICS writes all the listed events with their details as a calendar
+func writeICS(ctx context.Context, p provider.Provider, w io.Writer) error {
+	events, err := p.GetEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get events: %w", err)
+	}
+	items, err := export.Collect(ctx, p, events.Events)
+	if err != nil {
+		return err
+	}
+	return export.WriteICS(w, items, time.Now())
+}
+
+// writeFeed writes the listed events as a feed, the details are fetched only when they are included
+func writeFeed(format export.FeedFormat, link string, details bool) writeFn {
+	return func(ctx context.Context, p provider.Provider, w io.Writer) error {
+		events, err := p.GetEvents(ctx)
+		if err != nil {
+			return fmt.Errorf("could not get events: %w", err)
+		}
+		items := make([]export.Item, 0, len(events.Events))
+		if details {
+			if items, err = export.Collect(ctx, p, events.Events); err != nil {
+				return err
+			}
+		} else {
+			for _, e := range events.Events {
+				items = append(items, export.Item{Event: e})
+			}
+		}
+		info := export.FeedInfo{Title: export.CalendarName, Link: link, Updated: events.UpdatedAt}
+		return export.WriteFeed(w, format, info, items)
+	}
+}
+
+func runConfig(output string) (RunConfig, error) {
+	pc, err := providers.FlagConfig("export")
+	if err != nil {
+		return RunConfig{}, err
+	}
+	return RunConfig{
+		Provider: pc,
+		Output:   output,
+		Timeout:  v.GetDuration("export_timeout"),
+		Verbose:  v.GetBool("verbose"),
+	}, nil
+}
+
+var Cmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the events for other applications",
+	Long:  "Exports the events into formats used by other applications",
+}
+
+var icsCmd = &cobra.Command{
+	Use:   "ics",
+	Short: "Exports the events as an iCalendar file",
+	Long:  "Exports the events with their details as an iCalendar (.ics) file which can be imported into calendars",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := runConfig(v.GetString("export_ics_output"))
+		if err != nil {
+			return err
+		}
+		return Run(c, writeICS)
+	},
+}
+
+var feedCmd = &cobra.Command{
+	Use:   "feed",
+	Short: "Exports the events as an Atom or RSS feed",
+	Long:  "Exports the events as an Atom or RSS feed with an entry for each event which can be followed with feed readers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := export.ParseFeedFormat(v.GetString("export_feed_format"))
+		if err != nil {
+			return err
+		}
+		output := v.GetString("export_feed_output")
+		if len(output) == 0 {
+			output = "lutakkols." + string(format)
+		}
+		c, err := runConfig(output)
+		if err != nil {
+			return err
+		}
+		return Run(c, writeFeed(format, c.Provider.Address, v.GetBool("export_feed_details")))
+	},
+}
+
+func init() {
+	Cmd.AddCommand(icsCmd)
+	Cmd.AddCommand(feedCmd)
+
+	providers.AddFlags(Cmd.PersistentFlags(), "export", "offline")
+	Cmd.PersistentFlags().DurationP("timeout", "t", 2*time.Minute, "Timeout for getting the events and their details")
+	icsCmd.Flags().StringP("output", "o", "lutakkols.ics", "File to write, - for stdout")
+	feedCmd.Flags().StringP("format", "f", string(export.Atom), "Feed format: atom or rss")
+	feedCmd.Flags().StringP("output", "o", "", "File to write, - for stdout, defaults to lutakkols.<format>")
+	feedCmd.Flags().Bool("details", true, "Include the descriptions of the events which requires getting their details")
+
+	err := v.BindPFlag("export_timeout", Cmd.PersistentFlags().Lookup("timeout"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("export_ics_output", icsCmd.Flags().Lookup("output"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	for _, f := range []string{"format", "output", "details"} {
+		err = v.BindPFlag("export_feed_"+f, feedCmd.Flags().Lookup(f))
+		if err != nil {
+			fmt.Printf("could not bind flag: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/replay"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"time"
+)
+
+type RecordConfig struct {
+	SourceURL  string
+	OutputDir  string
+	EventLimit int
+	Timeout    time.Duration
+	Verbose    bool
+}
+
+// Record fetches the listing and the pages and images of the first events while recording the responses
+func Record(conf RecordConfig) error {
+	if conf.Verbose {
+		logger.SetLogger(&logger.StdOutLogger{})
+	}
+	fetch.SetTransport(replay.Recorder{Dir: conf.OutputDir})
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+
+	logger.Log.Infof("Recording %s into %s", conf.SourceURL, conf.OutputDir)
+	events, err := fetch.Sync.Events(ctx, conf.SourceURL)
+	if err != nil {
+		return err
+	}
+
+	for i, e := range events {
+		if i >= conf.EventLimit {
+			break
+		}
+		ed, err := fetch.Sync.EventDetails(ctx, e.EventURL(), e.ID())
+		if err != nil {
+			logger.Log.Warnf("could not record details for %s: %v", e.ID(), err)
+			continue
+		}
+		if _, err = fetch.Sync.EventImage(ctx, ed.ImageURL(), ed.ID()); err != nil {
+			logger.Log.Warnf("could not record image for %s: %v", e.ID(), err)
+		}
+	}
+
+	fmt.Printf("Recorded the listing and %d events into %s\n", min(conf.EventLimit, len(events)), conf.OutputDir)
+	return nil
+}
+
+var Cmd = &cobra.Command{
+	Use:   "fixtures",
+	Short: "Manages the scraper test fixtures",
+	Long:  "Manages the recorded responses used to test the scraper",
+}
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Records responses from the source",
+	Long:  "Records the listing, event pages and images from the source into the fixtures directory",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := RecordConfig{
+			SourceURL:  v.GetString("record_url"),
+			OutputDir:  v.GetString("record_dir"),
+			EventLimit: v.GetInt("record_limit"),
+			Timeout:    v.GetDuration("record_timeout"),
+			Verbose:    v.GetBool("verbose"),
+		}
+		return Record(c)
+	},
+}
+
+func init() {
+	Cmd.AddCommand(recordCmd)
+
+	recordCmd.Flags().StringP("input_url", "i", "https://www.jelmu.net", "URL to record")
+	recordCmd.Flags().StringP("output_dir", "o", "pkg/fetch/test_data/fixtures", "Directory to record into")
+	recordCmd.Flags().IntP("event_limit", "l", 2, "how many events to record")
+	recordCmd.Flags().DurationP("timeout", "t", time.Minute, "timeout for recording")
+
+	err := v.BindPFlag("record_url", recordCmd.Flags().Lookup("input_url"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("record_dir", recordCmd.Flags().Lookup("output_dir"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("record_limit", recordCmd.Flags().Lookup("event_limit"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("record_timeout", recordCmd.Flags().Lookup("timeout"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+}
@@ -0,0 +1,159 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/exitcode"
+	"github.com/johannessarpola/lutakkols/cmd/providers"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/filter"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/render"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"os"
+	"strings"
+	"time"
+)
+
+type RunConfig struct {
+	Provider providers.Config
+	Format   string
+	Template string
+	Filter   filter.Filter
+	Timeout  time.Duration
+	Verbose  bool
+}
+
+// Run prints the events matching the filter, it fails with exitcode.NoMatch when nothing matched
+func Run(conf RunConfig) error {
+	if conf.Verbose {
+		logger.SetLogger(&logger.StdOutLogger{})
+	}
+
+	r, err := Renderer(conf.Format, conf.Template)
+	if err != nil {
+		return err
+	}
+	p, err := providers.New(conf.Provider)
+	if err != nil {
+		return fmt.Errorf("could not create provider: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+	events, err := p.GetEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get events: %w", err)
+	}
+
+	matched := conf.Filter.Apply(events.Events)
+	if matched == nil {
+		matched = []models.Event{}
+	}
+	if err = r.Events(os.Stdout, matched); err != nil {
+		return err
+	}
+	if len(matched) == 0 {
+		return exitcode.New(exitcode.NoMatch, errors.New("no events matched"))
+	}
+	return nil
+}
+
+// Renderer creates the renderer for the format, a template implies the template format
+func Renderer(format string, tmpl string) (*render.Renderer, error) {
+	if len(tmpl) > 0 {
+		format = string(render.Template)
+	}
+	f, err := render.ParseFormat(format)
+	if err != nil {
+		return nil, exitcode.New(exitcode.Usage, err)
+	}
+	r, err := render.New(f, tmpl)
+	if err != nil {
+		return nil, exitcode.New(exitcode.Usage, err)
+	}
+	return r, nil
+}
+
+// flagFilter reads the filter from the flags
+func flagFilter() (filter.Filter, error) {
+	var f filter.Filter
+	var err error
+	if from := v.GetString("list_from"); len(from) > 0 {
+		if f.From, err = filter.Day(from); err != nil {
+			return f, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if to := v.GetString("list_to"); len(to) > 0 {
+		if f.To, err = filter.Day(to); err != nil {
+			return f, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	inStock, soldOut := v.GetBool("list_in_stock"), v.GetBool("list_sold_out")
+	switch {
+	case inStock && soldOut:
+		return f, errors.New("in_stock and sold_out can not be used together")
+	case inStock, soldOut:
+		f.InStock = &inStock
+	}
+	f.Text = strings.TrimSpace(v.GetString("list_keyword"))
+	return f, nil
+}
+
+var Cmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the events",
+	Long: "Lists the events as a table, json, ndjson, csv, yaml or with a Go template. Exits with 3 when no events " +
+		"matched the filters.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		pc, err := providers.FlagConfig("list")
+		if err != nil {
+			return exitcode.New(exitcode.Usage, err)
+		}
+		f, err := flagFilter()
+		if err != nil {
+			return exitcode.New(exitcode.Usage, err)
+		}
+		c := RunConfig{
+			Provider: pc,
+			Format:   v.GetString("list_format"),
+			Template: v.GetString("list_template"),
+			Filter:   f,
+			Timeout:  v.GetDuration("list_timeout"),
+			Verbose:  v.GetBool("verbose"),
+		}
+		return Run(c)
+	},
+}
+
+// FormatHelp is the help of the format flag
+var FormatHelp = func() string {
+	var names []string
+	for _, f := range render.Formats {
+		names = append(names, string(f))
+	}
+	return "Output format: " + strings.Join(names, ", ")
+}()
+
+func init() {
+	providers.AddFlags(Cmd.Flags(), "list", "online")
+	Cmd.Flags().StringP("format", "f", string(render.Table), FormatHelp)
+	Cmd.Flags().String("template", "", "Go template executed for each event, implies the template format")
+	Cmd.Flags().String("from", "", "Only events on or after the day, for example 2024-10-18")
+	Cmd.Flags().String("to", "", "Only events on or before the day")
+	Cmd.Flags().Bool("in_stock", false, "Only events with tickets in stock")
+	Cmd.Flags().Bool("sold_out", false, "Only sold out events")
+	Cmd.Flags().StringP("keyword", "k", "", "Only events with the keyword in the headline or bullet points")
+	Cmd.Flags().DurationP("timeout", "t", time.Minute, "Timeout for getting the events")
+
+	for _, f := range []string{"format", "template", "from", "to", "in_stock", "sold_out", "keyword", "timeout"} {
+		err := v.BindPFlag("list_"+f, Cmd.Flags().Lookup(f))
+		if err != nil {
+			fmt.Printf("could not bind flag: %v\n", err)
+		}
+	}
+}
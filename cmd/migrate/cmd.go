@@ -0,0 +1,55 @@
+package migrate
+
+import (
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/constants"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/migrate"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"path"
+)
+
+type RunConfig struct {
+	EventsFn       string
+	EventDetailsFn string
+	Verbose        bool
+}
+
+func Run(conf RunConfig) error {
+	if conf.Verbose {
+		logger.SetLogger(&logger.StdOutLogger{})
+	}
+
+	logger.Log.Infof("Migrating event identities in %s and %s", conf.EventsFn, conf.EventDetailsFn)
+	rs, err := migrate.EventIDs(conf.EventsFn, conf.EventDetailsFn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Migrated %d of %d events and %d details, %d details without event\n", rs.Changed, rs.Events, rs.Details, len(rs.Orphans))
+	return nil
+}
+
+var Cmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrates data synced with older versions",
+	Long:  "Migrates events and event details synced with older versions to use stable event identities",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := v.GetString("data_dir")
+		c := RunConfig{
+			EventsFn:       path.Join(dir, constants.EventsFile),
+			EventDetailsFn: path.Join(dir, constants.EventsDetailsFile),
+			Verbose:        v.GetBool("verbose"),
+		}
+		return Run(c)
+	},
+}
+
+func init() {
+	Cmd.Flags().StringP("data_dir", "d", ".data", "Directory containing the synced data")
+
+	err := v.BindPFlag("data_dir", Cmd.Flags().Lookup("data_dir"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+}
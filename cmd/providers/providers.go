@@ -0,0 +1,90 @@
+// Package providers constructs the providers for the commands from the command line configuration
+package providers
+
+import (
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/constants"
+	"github.com/johannessarpola/lutakkols/internal/views"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"github.com/johannessarpola/lutakkols/pkg/api/provider"
+	"github.com/johannessarpola/lutakkols/pkg/sources"
+	"github.com/spf13/pflag"
+	v "github.com/spf13/viper"
+	"path"
+	"strings"
+)
+
+// Kinds are the names of the provider kinds accepted by ParseKind
+var Kinds = []string{"online", "offline", "store", "hybrid"}
+
+// ParseKind parses the name of the provider kind
+func ParseKind(name string) (options.TypeOption, error) {
+	switch strings.ToLower(name) {
+	case "online":
+		return options.UseOnline, nil
+	case "offline":
+		return options.UseOffline, nil
+	case "store":
+		return options.UseStore, nil
+	case "hybrid":
+		return options.UseHybrid, nil
+	default:
+		return 0, fmt.Errorf("unknown provider %q, expected one of %s", name, strings.Join(Kinds, ", "))
+	}
+}
+
+// Config is what is needed to construct any kind of provider, Address and Sources are used online and InputDir
+// has the synced data for the others
+type Config struct {
+	Kind     options.TypeOption
+	Address  string
+	Sources  []string
+	InputDir string
+}
+
+// New constructs the provider of the configured kind
+func New(c Config) (provider.Provider, error) {
+	pc := provider.Config{
+		EventsSourceURL: c.Address,
+		Sources:         c.Sources,
+		AsciiGen:        views.GenerateOfflineAscii,
+	}
+	switch c.Kind {
+	case options.UseOffline, options.UseHybrid:
+		pc.EventSourceFsPath = path.Join(c.InputDir, constants.EventsFile)
+		pc.EventDetailsFsPath = path.Join(c.InputDir, constants.EventsDetailsFile)
+		pc.EventAsciiFsPath = path.Join(c.InputDir, constants.EventsAsciiFile)
+	case options.UseStore:
+		pc.StorePath = path.Join(c.InputDir, constants.StoreFile)
+	}
+	return provider.New(&pc, c.Kind)
+}
+
+// AddFlags adds the flags to select the provider into the command, they are bound into viper with the key prefix
+func AddFlags(flags *pflag.FlagSet, prefix string, defaultKind string) {
+	flags.StringP("provider", "p", defaultKind, "Provider to use: "+strings.Join(Kinds, ", "))
+	flags.StringP("address", "a", sources.JelmuURL, "Server address, used when no sources are given")
+	flags.StringSlice("sources", nil, "Sources to aggregate as name or name=url, available: "+strings.Join(sources.Names(), ", "))
+	flags.StringP("input_dir", "i", ".data", "Directory of the synced data for offline, store and hybrid providers")
+
+	for _, f := range []string{"provider", "address", "sources", "input_dir"} {
+		err := v.BindPFlag(prefix+"_"+f, flags.Lookup(f))
+		if err != nil {
+			fmt.Printf("could not bind flag: %v\n", err)
+		}
+	}
+}
+
+// FlagConfig reads the configuration of the provider from the flags added with the key prefix
+func FlagConfig(prefix string) (Config, error) {
+	kind, err := ParseKind(v.GetString(prefix + "_provider"))
+	if err != nil {
+		return Config{}, err
+	}
+	return Config{
+		Kind:     kind,
+		Address:  v.GetString(prefix + "_address"),
+		Sources:  v.GetStringSlice(prefix + "_sources"),
+		InputDir: v.GetString(prefix + "_input_dir"),
+	}, nil
+}
@@ -0,0 +1,98 @@
+package selectors
+
+import (
+	"context"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/httpcache"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/selectors"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+type CheckConfig struct {
+	ListingURL string
+	EventURL   string
+	Timeout    time.Duration
+}
+
+// Check runs the profile in use against the listing and an event page and prints the match count of each selector,
+// returns an error if any required selector matched nothing
+func Check(conf CheckConfig) error {
+	ctx, cancel := context.WithTimeout(httpcache.WithRevalidate(context.Background()), conf.Timeout)
+	defer cancel()
+
+	p := selectors.Current()
+	checks, err := fetch.CheckSelectors(ctx, p, conf.ListingURL, conf.EventURL)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile %s (version %d)\n\n", p.Name, p.Version)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SELECTOR\tMATCHES\tPAGE")
+	missed := 0
+	for _, c := range checks {
+		status := ""
+		if c.Missed() {
+			status = " !"
+			missed++
+		} else if c.Matches == 0 {
+			status = " (optional)"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d%s\t%s\n", c.Name, c.Matches, status, c.Page)
+	}
+	_ = w.Flush()
+
+	if missed > 0 {
+		return fmt.Errorf("%d selectors matched nothing", missed)
+	}
+	return nil
+}
+
+var Cmd = &cobra.Command{
+	Use:   "selectors",
+	Short: "Manages the scraper selector profiles",
+	Long:  "Manages the selector profiles used to scrape the source, use --selectors to run with another profile",
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Checks the selector profile against the source",
+	Long:  "Runs the selector profile against the listing and an event page and reports the selectors which matched nothing",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		c := CheckConfig{
+			ListingURL: v.GetString("check_url"),
+			EventURL:   v.GetString("check_event_url"),
+			Timeout:    v.GetDuration("check_timeout"),
+		}
+		return Check(c)
+	},
+}
+
+func init() {
+	Cmd.AddCommand(checkCmd)
+
+	checkCmd.Flags().StringP("input_url", "i", "https://www.jelmu.net", "URL of the listing page")
+	checkCmd.Flags().StringP("event_url", "e", "", "URL of an event page, defaults to the first event of the listing")
+	checkCmd.Flags().DurationP("timeout", "t", 30*time.Second, "timeout for the check")
+
+	err := v.BindPFlag("check_url", checkCmd.Flags().Lookup("input_url"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("check_event_url", checkCmd.Flags().Lookup("event_url"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("check_timeout", checkCmd.Flags().Lookup("timeout"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+}
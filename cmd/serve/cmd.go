@@ -0,0 +1,85 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/providers"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/server"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+type RunConfig struct {
+	Listen          string
+	Provider        providers.Config
+	ShutdownTimeout time.Duration
+	Calendar        bool
+	Feed            bool
+	Verbose         bool
+}
+
+// Run serves the provider until interrupted
+func Run(conf RunConfig) error {
+	if conf.Verbose {
+		logger.SetLogger(&logger.StdOutLogger{})
+	}
+
+	p, err := providers.New(conf.Provider)
+	if err != nil {
+		return fmt.Errorf("could not create provider: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := server.New(p)
+	if conf.Calendar {
+		srv = srv.WithCalendar()
+	}
+	if conf.Feed {
+		srv = srv.WithFeed(conf.Provider.Address)
+	}
+	fmt.Printf("Serving events on %s\n", conf.Listen)
+	return server.ListenAndServe(ctx, conf.Listen, srv, conf.ShutdownTimeout)
+}
+
+var Cmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves the events over HTTP",
+	Long:  "Serves the events as a read-only JSON API with /events, /events/{id}, /events/{id}/details, /events/{id}/ascii and /health",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pc, err := providers.FlagConfig("serve")
+		if err != nil {
+			return err
+		}
+		c := RunConfig{
+			Listen:          v.GetString("serve_listen"),
+			Provider:        pc,
+			ShutdownTimeout: v.GetDuration("serve_shutdown_timeout"),
+			Calendar:        v.GetBool("serve_ics"),
+			Feed:            v.GetBool("serve_feed"),
+			Verbose:         v.GetBool("verbose"),
+		}
+		return Run(c)
+	},
+}
+
+func init() {
+	providers.AddFlags(Cmd.Flags(), "serve", "online")
+	Cmd.Flags().String("listen", ":8080", "Address to listen on")
+	Cmd.Flags().Duration("shutdown_timeout", 10*time.Second, "How long pending requests have to finish when stopping")
+	Cmd.Flags().Bool("ics", false, "Serve the events also as an iCalendar feed in /events.ics")
+	Cmd.Flags().Bool("feed", false, "Serve the events also as Atom and RSS feeds in /events.atom and /events.rss")
+
+	for _, f := range []string{"listen", "shutdown_timeout", "ics", "feed"} {
+		err := v.BindPFlag("serve_"+f, Cmd.Flags().Lookup(f))
+		if err != nil {
+			fmt.Printf("could not bind flag: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package show
+
+import (
+	"context"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/exitcode"
+	"github.com/johannessarpola/lutakkols/cmd/list"
+	"github.com/johannessarpola/lutakkols/cmd/providers"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/render"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"os"
+	"time"
+)
+
+type RunConfig struct {
+	Provider providers.Config
+	EventID  string
+	Format   string
+	Template string
+	Timeout  time.Duration
+	Verbose  bool
+}
+
+// Run prints the event with its details, it fails with exitcode.NotFound when there is no such event. The event is
+// printed without the details when they can not be fetched.
+func Run(conf RunConfig) error {
+	if conf.Verbose {
+		logger.SetLogger(&logger.StdOutLogger{})
+	}
+
+	r, err := list.Renderer(conf.Format, conf.Template)
+	if err != nil {
+		return err
+	}
+	p, err := providers.New(conf.Provider)
+	if err != nil {
+		return fmt.Errorf("could not create provider: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+	events, err := p.GetEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get events: %w", err)
+	}
+
+	for _, e := range events.Events {
+		if e.ID() != conf.EventID {
+			continue
+		}
+		d := render.Detailed{Event: e}
+		ed, err := p.GetDetails(ctx, e.ID(), e.EventURL())
+		if err != nil {
+			logger.Log.Warnf("could not get details of %s: %v", e.ID(), err)
+			_, _ = fmt.Fprintf(os.Stderr, "could not get details: %v\n", err)
+		} else {
+			d.Details = &ed
+		}
+		return r.Event(os.Stdout, d)
+	}
+	return exitcode.New(exitcode.NotFound, fmt.Errorf("no event with id %s", conf.EventID))
+}
+
+var Cmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Shows an event",
+	Long: "Shows the event with its details as a table, json, ndjson, csv, yaml or with a Go template. Exits with 4 " +
+		"when there is no such event.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		pc, err := providers.FlagConfig("show")
+		if err != nil {
+			return exitcode.New(exitcode.Usage, err)
+		}
+		c := RunConfig{
+			Provider: pc,
+			EventID:  args[0],
+			Format:   v.GetString("show_format"),
+			Template: v.GetString("show_template"),
+			Timeout:  v.GetDuration("show_timeout"),
+			Verbose:  v.GetBool("verbose"),
+		}
+		return Run(c)
+	},
+}
+
+func init() {
+	providers.AddFlags(Cmd.Flags(), "show", "online")
+	Cmd.Flags().StringP("format", "f", string(render.Table), list.FormatHelp)
+	Cmd.Flags().String("template", "", "Go template executed with .Event and .Details, implies the template format")
+	Cmd.Flags().DurationP("timeout", "t", time.Minute, "Timeout for getting the event and its details")
+
+	for _, f := range []string{"format", "template", "timeout"} {
+		err := v.BindPFlag("show_"+f, Cmd.Flags().Lookup(f))
+		if err != nil {
+			fmt.Printf("could not bind flag: %v\n", err)
+		}
+	}
+}
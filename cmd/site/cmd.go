@@ -0,0 +1,88 @@
+package site
+
+import (
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/constants"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/site"
+	"github.com/johannessarpola/lutakkols/pkg/snapshot"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"path"
+)
+
+type RunConfig struct {
+	InputDir  string
+	OutputDir string
+	Templates string
+	Title     string
+	Verbose   bool
+}
+
+// Run renders the data synced into the input directory as a static site
+func Run(conf RunConfig) error {
+	if conf.Verbose {
+		logger.SetLogger(&logger.StdOutLogger{})
+	}
+
+	snap, err := snapshot.Load(snapshot.Paths{
+		Events:  path.Join(conf.InputDir, constants.EventsFile),
+		Details: path.Join(conf.InputDir, constants.EventsDetailsFile),
+		Ascii:   path.Join(conf.InputDir, constants.EventsAsciiFile),
+	})
+	if err != nil {
+		return fmt.Errorf("could not load %s: %w", conf.InputDir, err)
+	}
+
+	err = site.Build(snap, site.Config{
+		OutputDir: conf.OutputDir,
+		Templates: conf.Templates,
+		Title:     conf.Title,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Built the site of %d events into %s\n", len(snap.Events), conf.OutputDir)
+	return nil
+}
+
+var Cmd = &cobra.Command{
+	Use:   "site",
+	Short: "Static site from the synced data",
+	Long:  "Static HTML site from the synced data",
+}
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Builds a static site from the synced data",
+	Long: "Builds a static HTML site with an index, a page for each event and month calendars from the synced data. " +
+		"The templates layout.html, index.html, event.html, calendar.html and style.css can be overridden with files " +
+		"of the same name in the templates directory.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := RunConfig{
+			InputDir:  v.GetString("site_input_dir"),
+			OutputDir: v.GetString("site_output_dir"),
+			Templates: v.GetString("site_templates"),
+			Title:     v.GetString("site_title"),
+			Verbose:   v.GetBool("verbose"),
+		}
+		return Run(c)
+	},
+}
+
+func init() {
+	Cmd.AddCommand(buildCmd)
+
+	buildCmd.Flags().StringP("input_dir", "i", ".data", "Directory of the synced data")
+	buildCmd.Flags().StringP("output_dir", "o", "site", "Directory to write the site into")
+	buildCmd.Flags().String("templates", "", "Directory of templates overriding the embedded ones")
+	buildCmd.Flags().String("title", site.DefaultTitle, "Title of the site")
+
+	for _, f := range []string{"input_dir", "output_dir", "templates", "title"} {
+		err := v.BindPFlag("site_"+f, buildCmd.Flags().Lookup(f))
+		if err != nil {
+			fmt.Printf("could not bind flag: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"fmt"
+	"github.com/johannessarpola/lutakkols/cmd/constants"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/store"
+	"github.com/spf13/cobra"
+	v "github.com/spf13/viper"
+	"path"
+)
+
+type ImportConfig struct {
+	EventsFn       string
+	EventDetailsFn string
+	EventAsciiFn   string
+	StoreFn        string
+	Verbose        bool
+}
+
+// Import imports the json files written by sync into the store
+func Import(conf ImportConfig) error {
+	if conf.Verbose {
+		logger.SetLogger(&logger.StdOutLogger{})
+	}
+
+	s, err := store.Open(conf.StoreFn)
+	if err != nil {
+		return err
+	}
+	defer func(s *store.Store) {
+		_ = s.Close()
+	}(s)
+
+	logger.Log.Infof("Importing %s and %s into %s", conf.EventsFn, conf.EventDetailsFn, conf.StoreFn)
+	rs, err := s.ImportJson(conf.EventsFn, conf.EventDetailsFn)
+	if err != nil {
+		return err
+	}
+	ascii, err := s.ImportAscii(conf.EventAsciiFn)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d events, %d details and %d ascii images into %s\n", rs.Events, rs.Details, ascii, conf.StoreFn)
+	return nil
+}
+
+var Cmd = &cobra.Command{
+	Use:   "store",
+	Short: "Manages the SQLite store",
+	Long:  "Manages the SQLite store used with the store mode",
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Imports synced json files into the store",
+	Long:  "Imports events and event details json files written by sync into the store",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := v.GetString("import_dir")
+		c := ImportConfig{
+			EventsFn:       path.Join(dir, constants.EventsFile),
+			EventDetailsFn: path.Join(dir, constants.EventsDetailsFile),
+			EventAsciiFn:   path.Join(dir, constants.EventsAsciiFile),
+			StoreFn:        path.Join(dir, constants.StoreFile),
+			Verbose:        v.GetBool("verbose"),
+		}
+		return Import(c)
+	},
+}
+
+func init() {
+	Cmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringP("data_dir", "d", ".data", "Directory containing the synced data and the store")
+
+	err := v.BindPFlag("import_dir", importCmd.Flags().Lookup("data_dir"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+}
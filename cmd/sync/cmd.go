@@ -4,82 +4,180 @@ import (
 	"context"
 	"fmt"
 	"github.com/johannessarpola/lutakkols/cmd/constants"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/diff"
 	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/httpcache"
 	"github.com/johannessarpola/lutakkols/pkg/logger"
-	"github.com/johannessarpola/lutakkols/pkg/writer"
-	"github.com/johannessarpola/pipes"
+	"github.com/johannessarpola/lutakkols/pkg/ratelimit"
+	"github.com/johannessarpola/lutakkols/pkg/snapshot"
+	"github.com/johannessarpola/lutakkols/pkg/sources"
+	"github.com/johannessarpola/lutakkols/pkg/store"
 	"github.com/spf13/cobra"
 	v "github.com/spf13/viper"
+	"os"
 	"path"
+	"strings"
 	"time"
 )
 
 type RunConfig struct {
 	SourceURL      string
+	Sources        []string
 	EventsFn       string
 	EventDetailsFn string
+	EventAsciiFn   string
+	StoreFn        string
 	Timeout        time.Duration
 	RateLimit      time.Duration
-	EventLimit     int
+	Concurrency    int
+	TaskTimeout    time.Duration
+	DetailsLimit   int
+	Full           bool
+	DiffFormat     string
 	Verbose        bool
 }
 
+// source resolves the sources to sync, without them the jelmu source is used from SourceURL
+func (conf RunConfig) source() (sources.Source, error) {
+	if len(conf.Sources) > 0 {
+		return sources.FromSpecs(conf.Sources...)
+	}
+	return sources.New(sources.Jelmu, conf.SourceURL)
+}
+
 func Run(conf RunConfig) {
 	start := time.Now()
 	if conf.Verbose {
 		logger.SetLogger(&logger.StdOutLogger{})
 	}
 
+	if len(conf.DiffFormat) > 0 {
+		if _, err := diff.ParseFormat(conf.DiffFormat); err != nil {
+			logger.Log.Errorf("Invalid diff format: %v", err)
+			fmt.Println(err)
+			return
+		}
+	}
+
+	src, err := conf.source()
+	if err != nil {
+		logger.Log.Errorf("Could not create sources: %v", err)
+		return
+	}
+
 	timeout := conf.Timeout
-	logger.Log.Infof("Starting sync with timeout %v against URL %s writing events to %s and details to %s", timeout, conf.SourceURL, conf.EventsFn, conf.EventDetailsFn)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	logger.Log.Infof("Starting sync with timeout %v against %s writing events to %s and details to %s", timeout, src.Name(), conf.EventsFn, conf.EventDetailsFn)
+	// the cached responses are always revalidated to sync the latest data
+	ctx, cancel := context.WithTimeout(httpcache.WithRevalidate(context.Background()), timeout)
 	defer cancel()
 
-	events := fetch.Async.Events(conf.SourceURL, conf.EventLimit, ctx)
-	e1, e2 := pipes.FanOut(ctx, events)
-
-	logger.Log.Infof("Writing events into %s", conf.EventsFn)
-
-	eventWriteChan := make(chan pipes.Result[bool])
-	detailsWriteChan := make(chan pipes.Result[bool])
-
-	eventWriteChan = writer.WriteChannel(e1, conf.EventsFn, timeout)
-
-	rateLimitedEvents := pipes.ThrottleChannel(ctx, e2, time.Second)
-	detailResults := fetch.Async.Details(ctx, rateLimitedEvents)
-	details := pipes.FilterError(ctx, detailResults, func(err error) {
-		logger.Log.Warn("details error ", err)
-	})
-	detailsWriteChan = writer.WriteChannel(details, conf.EventDetailsFn, timeout)
-
-	var dwr1, dwr2 bool
-
-	for {
-		select {
-		case eventWrite := <-eventWriteChan:
-			if eventWrite.Err != nil {
-				logger.Log.Error("Could not write events", eventWrite.Err)
-			}
-			if !dwr1 {
-				logger.Log.Infof("Events written successfully to %s", conf.EventsFn)
-			}
-			dwr1 = true
-		case detailsWrite := <-detailsWriteChan:
-			if detailsWrite.Err != nil {
-				logger.Log.Error("Could not write event details", detailsWrite.Err)
-			}
-			if !dwr2 {
-				logger.Log.Infof("Event details written successfully to %s", conf.EventDetailsFn)
-			}
-			dwr2 = true
-		}
-		if dwr1 && dwr2 {
-			break
+	paths := snapshot.Paths{Events: conf.EventsFn, Details: conf.EventDetailsFn, Ascii: conf.EventAsciiFn}
+	prev, err := snapshot.Load(paths)
+	if err != nil {
+		logger.Log.Errorf("Could not load the previous sync: %v", err)
+		return
+	}
+
+	events, unlisted, err := sources.List(ctx, src)
+	if err != nil {
+		logger.Log.Errorf("Could not list events: %v", err)
+		fmt.Printf("Could not list events, %s: %v\n", fetch.Diagnose(err), err)
+		return
+	}
+
+	stale := events
+	if !conf.Full {
+		stale = prev.Stale(events)
+	}
+	if conf.DetailsLimit > 0 && len(stale) > conf.DetailsLimit {
+		stale = stale[:conf.DetailsLimit]
+	}
+
+	pool := sources.PoolConfig{
+		Workers:     conf.Concurrency,
+		TaskTimeout: conf.TaskTimeout,
+		Limiter:     ratelimit.New(conf.RateLimit, conf.Concurrency),
+	}
+	logger.Log.Infof("Fetching details of %d/%d events with %d workers limited to a request per %v", len(stale), len(events), pool.Workers, conf.RateLimit)
+	details, failures := sources.FetchDetails(ctx, src, stale, pool)
+	reportFailures("details", failures)
+
+	var ascii []models.EventAscii
+	if len(conf.EventAsciiFn) > 0 {
+		missing := details
+		if !conf.Full {
+			missing = prev.MissingAscii(details)
 		}
+		ascii, failures = sources.FetchImages(ctx, src, missing, pool)
+		reportFailures("images", failures)
+	}
+
+	merged, summary := prev.Merge(events, details, ascii, unlisted...)
+	if err = merged.Write(paths); err != nil {
+		logger.Log.Errorf("Could not write the sync: %v", err)
+		return
+	}
+	logger.Log.Infof("Wrote %d new, %d changed, %d unchanged and %d archived events into %s, %s and %s",
+		summary.New, summary.Changed, summary.Unchanged, summary.Archived, conf.EventsFn, conf.EventDetailsFn, conf.EventAsciiFn)
+	if len(unlisted) > 0 {
+		logger.Log.Warnf("Kept the previous %d events of %s which could not be listed", summary.Kept, strings.Join(unlisted, ", "))
+	}
+
+	if len(conf.DiffFormat) > 0 {
+		printDiff(prev, merged, conf.DiffFormat)
+	}
+
+	if len(conf.StoreFn) > 0 {
+		writeStore(conf)
 	}
 	logger.Log.Infof("Doneso in %d ms!", time.Since(start).Milliseconds())
 }
 
+// printDiff prints the changes of the sync, the format has been validated before syncing
+func printDiff(prev snapshot.Snapshot, merged snapshot.Snapshot, format string) {
+	f, _ := diff.ParseFormat(format)
+	if err := diff.Compare(prev, merged).Write(os.Stdout, f); err != nil {
+		logger.Log.Errorf("Could not write the diff: %v", err)
+	}
+}
+
+// reportFailures prints the events which could not be fetched, they are logged as well
+func reportFailures(kind string, failures []sources.EventError) {
+	if len(failures) == 0 {
+		return
+	}
+	fmt.Printf("Could not fetch %s for %d events:\n", kind, len(failures))
+	for _, f := range failures {
+		logger.Log.Warnf("Could not fetch %s: %v", kind, f)
+		fmt.Printf("  %s (%s): %v\n", f.EventID, fetch.Diagnose(f.Err), f.Err)
+	}
+}
+
+// writeStore imports the written files into the store
+func writeStore(conf RunConfig) {
+	s, err := store.Open(conf.StoreFn)
+	if err != nil {
+		logger.Log.Errorf("Could not open store %s: %v", conf.StoreFn, err)
+		return
+	}
+	defer func(s *store.Store) {
+		_ = s.Close()
+	}(s)
+
+	rs, err := s.ImportJson(conf.EventsFn, conf.EventDetailsFn)
+	if err != nil {
+		logger.Log.Errorf("Could not write into store %s: %v", conf.StoreFn, err)
+		return
+	}
+	ascii, err := s.ImportAscii(conf.EventAsciiFn)
+	if err != nil {
+		logger.Log.Errorf("Could not write ascii into store %s: %v", conf.StoreFn, err)
+		return
+	}
+	logger.Log.Infof("Wrote %d events, %d details and %d ascii images into store %s", rs.Events, rs.Details, ascii, conf.StoreFn)
+}
+
 var Cmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Syncs data",
@@ -88,20 +186,43 @@ var Cmd = &cobra.Command{
 
 		ep := path.Join(v.GetString("output_dir"), constants.EventsFile)
 		edp := path.Join(v.GetString("output_dir"), constants.EventsDetailsFile)
+		eap := ""
+		if v.GetBool("sync_ascii") {
+			eap = path.Join(v.GetString("output_dir"), constants.EventsAsciiFile)
+		}
 		op := v.GetString("input_url")
+		srcs := v.GetStringSlice("sync_sources")
 		to := v.GetDuration("timeout")
 		rl := v.GetDuration("rate_limit")
-		el := v.GetInt("event_limit")
+		cc := v.GetInt("concurrency")
+		tt := v.GetDuration("task_timeout")
+		dl := v.GetInt("details_limit")
+		full := v.GetBool("full")
+		df := ""
+		if v.GetBool("sync_diff") {
+			df = v.GetString("sync_diff_format")
+		}
 		verbose := v.GetBool("verbose")
+		sp := ""
+		if v.GetBool("sync_store") {
+			sp = path.Join(v.GetString("output_dir"), constants.StoreFile)
+		}
 
 		c := RunConfig{
 			SourceURL:      op,
+			Sources:        srcs,
 			EventsFn:       ep,
 			EventDetailsFn: edp,
+			EventAsciiFn:   eap,
+			StoreFn:        sp,
 			Timeout:        to,
 			RateLimit:      rl,
+			Concurrency:    cc,
+			TaskTimeout:    tt,
 			Verbose:        verbose,
-			EventLimit:     el,
+			DetailsLimit:   dl,
+			Full:           full,
+			DiffFormat:     df,
 		}
 
 		Run(c)
@@ -110,17 +231,30 @@ var Cmd = &cobra.Command{
 
 func init() {
 
-	Cmd.Flags().StringP("input_url", "i", "https://www.jelmu.net", "EventURL to source data")
+	Cmd.Flags().StringP("input_url", "i", sources.JelmuURL, "EventURL to source data, used when no sources are given")
+	Cmd.Flags().StringSlice("sources", nil, "Sources to sync as name or name=url, available: "+strings.Join(sources.Names(), ", "))
 	Cmd.Flags().StringP("output_dir", "o", ".data", "Output directory to write to")
 	Cmd.Flags().DurationP("timeout", "t", time.Second*120, "timeout for synchronization task")
-	Cmd.Flags().DurationP("rate_limit", "r", time.Second*1, "ratelimiter for requests")
-	Cmd.Flags().IntP("event_limit", "l", 0, "limit on how mnay events to fetch")
+	Cmd.Flags().DurationP("rate_limit", "r", time.Second*1, "minimum interval between requests, 0 disables the limit")
+	Cmd.Flags().IntP("concurrency", "c", 4, "how many events are fetched concurrently")
+	Cmd.Flags().Duration("task_timeout", sources.DefaultTaskTimeout, "timeout for fetching a single event")
+	Cmd.Flags().Int("details_limit", 0, "limit on how many new or changed events to fetch the details of, the listing is always synced fully")
+	Cmd.Flags().BoolP("full", "f", false, "fetch the details of all the events instead of only new and changed ones")
+	Cmd.Flags().Bool("diff", false, "print the changes compared to the previous sync")
+	Cmd.Flags().String("diff_format", string(diff.Text), "format of the changes: text, json or markdown")
+	Cmd.Flags().Bool("ascii", true, "download the event images and write them as ascii art for the offline mode")
+	Cmd.Flags().BoolP("store", "s", false, "write the synced data also into the SQLite store in output_dir")
 
 	err := v.BindPFlag("input_url", Cmd.Flags().Lookup("input_url"))
 	if err != nil {
 		fmt.Printf("could not bind flag: %v\n", err)
 	}
 
+	err = v.BindPFlag("sync_sources", Cmd.Flags().Lookup("sources"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
 	err = v.BindPFlag("output_dir", Cmd.Flags().Lookup("output_dir"))
 	if err != nil {
 		fmt.Printf("could not bind flag: %v\n", err)
@@ -136,7 +270,42 @@ func init() {
 		fmt.Printf("could not bind flag: %v\n", err)
 	}
 
-	err = v.BindPFlag("event_limit", Cmd.Flags().Lookup("event_limit"))
+	err = v.BindPFlag("full", Cmd.Flags().Lookup("full"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("concurrency", Cmd.Flags().Lookup("concurrency"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("task_timeout", Cmd.Flags().Lookup("task_timeout"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("details_limit", Cmd.Flags().Lookup("details_limit"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("sync_diff", Cmd.Flags().Lookup("diff"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("sync_diff_format", Cmd.Flags().Lookup("diff_format"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("sync_ascii", Cmd.Flags().Lookup("ascii"))
+	if err != nil {
+		fmt.Printf("could not bind flag: %v\n", err)
+	}
+
+	err = v.BindPFlag("sync_store", Cmd.Flags().Lookup("store"))
 	if err != nil {
 		fmt.Printf("could not bind flag: %v\n", err)
 	}
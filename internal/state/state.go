@@ -0,0 +1,148 @@
+// Package state keeps the state of the UI between runs in a small JSON file, such as the favourited events and how
+// the list is sorted
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Favourite is a favourited event, Event is the last seen version of it so that it can be shown after the source no
+// longer lists it
+type Favourite struct {
+	Event   models.Event `json:"event"`
+	AddedAt time.Time    `json:"added_at"`
+}
+
+// ListMode is how the events are sorted and grouped in the list, empty values are the defaults of the list
+type ListMode struct {
+	Sort  string `json:"sort,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+// State is the persisted state, it is saved into the file it was loaded from
+type State struct {
+	Favourites map[string]Favourite `json:"favourites"`
+	List       ListMode             `json:"list"`
+	path       string
+}
+
+// DefaultPath is the state file in the user config directory
+func DefaultPath() string {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "lutakkols", "state.json")
+}
+
+// New returns an empty state which is saved into the path
+func New(path string) *State {
+	return &State{
+		Favourites: map[string]Favourite{},
+		path:       path,
+	}
+}
+
+// Load reads the state from the path, a missing file is an empty state
+func Load(path string) (*State, error) {
+	s := New(path)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if s.Favourites == nil {
+		s.Favourites = map[string]Favourite{}
+	}
+	return s, nil
+}
+
+// Save writes the state into its file, the file is replaced only once the whole state has been written
+func (s *State) Save() error {
+	if len(s.path) == 0 {
+		return nil
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// IsFavourite checks if the event with the id is a favourite
+func (s *State) IsFavourite(id string) bool {
+	_, ok := s.Favourites[id]
+	return ok
+}
+
+// ToggleFavourite adds the event to the favourites or removes it, returns whether the event is a favourite after it
+func (s *State) ToggleFavourite(e models.Event) bool {
+	if s.IsFavourite(e.ID()) {
+		delete(s.Favourites, e.ID())
+		return false
+	}
+	s.Favourites[e.ID()] = Favourite{Event: e, AddedAt: time.Now()}
+	return true
+}
+
+// Reconcile updates the favourites with the listed events and returns the favourites which are no longer listed
+// ordered by when they were added, changed tells if the state needs to be saved
+func (s *State) Reconcile(events []models.Event) (unlisted []models.Event, changed bool) {
+	listed := map[string]bool{}
+	for _, e := range events {
+		f, ok := s.Favourites[e.ID()]
+		if !ok || e.Archived {
+			continue
+		}
+		listed[e.ID()] = true
+		if !sameEvent(f.Event, e) {
+			f.Event = e
+			s.Favourites[e.ID()] = f
+			changed = true
+		}
+	}
+
+	var missing []Favourite
+	for id, f := range s.Favourites {
+		if !listed[id] {
+			missing = append(missing, f)
+		}
+	}
+	slices.SortFunc(missing, func(a, b Favourite) int {
+		if c := a.AddedAt.Compare(b.AddedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Event.ID(), b.Event.ID())
+	})
+	for _, f := range missing {
+		unlisted = append(unlisted, f.Event)
+	}
+	return unlisted, changed
+}
+
+// sameEvent compares the events as they are persisted, the times read from the file differ only by their location
+func sameEvent(a models.Event, b models.Event) bool {
+	ab, aErr := json.Marshal(a)
+	bb, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(ab) == string(bb)
+}
@@ -0,0 +1,84 @@
+package state
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadMissing(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("expected a missing file to be an empty state, got %s", err)
+	}
+	if len(s.Favourites) != 0 {
+		t.Errorf("expected no favourites, got %v", s.Favourites)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatalf("could not write state: %s", err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Errorf("expected an error for an invalid state file")
+	}
+}
+
+func TestStateIsSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	s := New(path)
+	e := models.Event{Id: "event1", Headline: "Music Concert"}
+	if !s.ToggleFavourite(e) || !s.IsFavourite("event1") {
+		t.Fatalf("expected event1 to be a favourite")
+	}
+	s.List = ListMode{Sort: "price", Group: "month"}
+	if err := s.Save(); err != nil {
+		t.Fatalf("err saving state: %s", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("err loading state: %s", err)
+	}
+	if !loaded.IsFavourite("event1") || loaded.Favourites["event1"].Event.Headline != "Music Concert" {
+		t.Errorf("expected event1 to be loaded as a favourite, got %v", loaded.Favourites)
+	}
+	if loaded.List != s.List {
+		t.Errorf("expected the list mode %v, got %v", s.List, loaded.List)
+	}
+	if loaded.ToggleFavourite(e) || loaded.IsFavourite("event1") {
+		t.Errorf("expected event1 to be removed from favourites")
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	s := New("")
+	now := time.Now()
+	s.Favourites["event1"] = Favourite{Event: models.Event{Id: "event1", Headline: "Old headline"}, AddedAt: now}
+	s.Favourites["event3"] = Favourite{Event: models.Event{Id: "event3", Headline: "Gone"}, AddedAt: now.Add(time.Minute)}
+	s.Favourites["event2"] = Favourite{Event: models.Event{Id: "event2", Headline: "Archived"}, AddedAt: now.Add(-time.Minute)}
+
+	events := []models.Event{
+		{Id: "event1", Headline: "New headline"},
+		{Id: "event2", Headline: "Archived", Archived: true},
+		{Id: "event4", Headline: "Not a favourite"},
+	}
+	unlisted, changed := s.Reconcile(events)
+	if !changed || s.Favourites["event1"].Event.Headline != "New headline" {
+		t.Errorf("expected the favourite to be updated with the listed event")
+	}
+	if len(unlisted) != 2 || unlisted[0].ID() != "event2" || unlisted[1].ID() != "event3" {
+		t.Errorf("expected event2 and event3 to be unlisted in the order they were added, got %v", unlisted)
+	}
+	if s.IsFavourite("event4") {
+		t.Errorf("expected only favourites to be kept")
+	}
+
+	if _, changed = s.Reconcile(events); changed {
+		t.Errorf("expected no changes when the events are the same")
+	}
+}
@@ -2,8 +2,9 @@ package views
 
 import "github.com/charmbracelet/lipgloss"
 
+// GenerateOfflineAscii is the placeholder for events which do not have synced ascii art
 func GenerateOfflineAscii(_ string, url string) string {
-	na := "asici not available in offline mode:"
+	na := "ascii not synced for the image:"
 
 	block := lipgloss.JoinVertical(lipgloss.Top, na, url)
 	asc := lipgloss.Place(asciiWidth, asciiHeight, lipgloss.Center, lipgloss.Center, asciiPlaceholderStyle.Render(block), lipgloss.WithWhitespaceChars("."))
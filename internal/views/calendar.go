@@ -0,0 +1,421 @@
+package views
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johannessarpola/lutakkols/internal/views/cmd"
+	"github.com/johannessarpola/lutakkols/internal/views/constants"
+	"github.com/johannessarpola/lutakkols/internal/views/help"
+	"github.com/johannessarpola/lutakkols/internal/views/messages"
+	"github.com/johannessarpola/lutakkols/internal/views/spinner"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/provider"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"github.com/johannessarpola/lutakkols/pkg/filter"
+	"github.com/muesli/reflow/truncate"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	calendarMinCellWidth = 6
+	wideCellHeadlines    = 2
+)
+
+var (
+	selectedColor = lipgloss.AdaptiveColor{Light: "#4242f5", Dark: "#f5d742"}
+
+	calendarCellStyle     = lipgloss.NewStyle().Padding(0, 1)
+	calendarSelectedStyle = lipgloss.NewStyle().
+				Border(lipgloss.NormalBorder(), false, false, false, true).
+				BorderForeground(selectedColor).
+				Foreground(selectedColor).
+				PaddingRight(1)
+	calendarOtherMonthStyle = calendarCellStyle.Copy().Foreground(subduedColor)
+	calendarWeekdayStyle    = calendarCellStyle.Copy().Bold(true)
+	calendarDayListStyle    = lipgloss.NewStyle().MarginTop(1).PaddingLeft(2)
+)
+
+// CalendarView shows the events in a month grid, the events of the selected day are listed below the grid
+type CalendarView struct {
+	spinner     spinner.Model
+	help        help.Model
+	keyMap      CalendarKeymap
+	loading     bool
+	ready       bool
+	useWide     bool
+	width       int
+	days        map[string][]models.Event
+	unlisted    map[string]bool // the favourites which are no longer listed
+	day         time.Time
+	selected    int
+	provider    provider.Provider
+	ctx         context.Context
+	cancel      context.CancelFunc
+	appCtx      context.Context
+	session     *session
+	toast       toast
+	failure     *messages.Failed // the last failure which r retries until its toast expires
+	DataUpdated time.Time
+	DataOrigin  models.Origin
+}
+
+// dayKey is the key of the day in the calendar
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func today() time.Time {
+	now := time.Now().In(dates.Location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, dates.Location)
+}
+
+func newCalendarView(appCtx context.Context, provider provider.Provider, s *session) CalendarView {
+	ctx, cancel := context.WithCancel(appCtx)
+
+	cv := CalendarView{
+		ctx:         ctx,
+		cancel:      cancel,
+		appCtx:      appCtx,
+		session:     s,
+		provider:    provider,
+		loading:     true,
+		spinner:     newSpinner(),
+		help:        help.New(),
+		keyMap:      CalendarKeymap{},
+		days:        map[string][]models.Event{},
+		unlisted:    map[string]bool{},
+		day:         today(),
+		DataUpdated: time.Now(),
+	}
+	configureCalendar(constants.WindowSize, &cv)
+	return cv
+}
+
+// configureCalendar shows the headlines in the cells when the window is wide enough
+func configureCalendar(msg tea.WindowSizeMsg, m *CalendarView) {
+	m.width = msg.Width
+	if msg.Width > magicWidth {
+		m.useWide = true
+	} else {
+		m.useWide = false
+	}
+}
+
+func (m CalendarView) Init() tea.Cmd {
+	return tea.Sequence(m.spinner.Tick, cmd.GetEvents(m.ctx, m.provider))
+}
+
+// setEvents places the events with a known day into the calendar, the favourites which are no longer listed are
+// shown as well like in the list
+func (m *CalendarView) setEvents(events []models.Event) {
+	unlisted, changed := m.session.state.Reconcile(events)
+	if changed {
+		m.session.saveState()
+	}
+	m.unlisted = map[string]bool{}
+	for _, e := range unlisted {
+		m.unlisted[e.ID()] = true
+	}
+
+	m.days = map[string][]models.Event{}
+	var days []time.Time
+	for _, e := range slices.Concat(events, unlisted) {
+		if m.session.favouritesOnly && !m.session.state.IsFavourite(e.ID()) {
+			continue
+		}
+		d, ok := filter.StartDay(e)
+		if !ok {
+			continue
+		}
+		days = append(days, d)
+		m.days[dayKey(d)] = append(m.days[dayKey(d)], e)
+	}
+	for _, es := range m.days {
+		slices.SortStableFunc(es, func(a models.Event, b models.Event) int {
+			if c := compareMissingLast(a.StartsAt, b.StartsAt, time.Time.Compare); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.Order, b.Order)
+		})
+	}
+
+	// returning from an event keeps the day, otherwise the calendar starts from the next gig
+	if m.session.calendarDay != nil {
+		m.day = *m.session.calendarDay
+		return
+	}
+	slices.SortFunc(days, time.Time.Compare)
+	if i := slices.IndexFunc(days, func(d time.Time) bool { return !d.Before(today()) }); i >= 0 {
+		m.day = days[i]
+	} else if len(days) > 0 {
+		m.day = days[0]
+	}
+}
+
+func (m *CalendarView) move(days int) {
+	m.day = m.day.AddDate(0, 0, days)
+	m.selected = 0
+}
+
+// moveMonth moves to the same day of another month, or to its last day if the month is shorter
+func (m *CalendarView) moveMonth(months int) {
+	first := time.Date(m.day.Year(), m.day.Month(), 1, 0, 0, 0, 0, m.day.Location()).AddDate(0, months, 0)
+	last := first.AddDate(0, 1, -1).Day()
+	m.day = first.AddDate(0, 0, min(m.day.Day(), last)-1)
+	m.selected = 0
+}
+
+func (m CalendarView) dayEvents() []models.Event {
+	return m.days[dayKey(m.day)]
+}
+
+func (m CalendarView) selectedEvent() (models.Event, bool) {
+	events := m.dayEvents()
+	if m.selected < len(events) {
+		return events[m.selected], true
+	}
+	return models.Event{}, false
+}
+
+func (m CalendarView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var c tea.Cmd
+
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		m.spinner, c = m.spinner.Update(msg)
+		return m, c
+	case tea.WindowSizeMsg:
+		constants.WindowSize = msg
+		configureCalendar(msg, &m)
+	case messages.Failed:
+		m.failure = &msg
+		m.loading = false
+		if !m.ready {
+			// without the events there is nothing to show but the error
+			return m, nil
+		}
+		return m, m.toast.showFailure(msg)
+	case toastExpired:
+		if m.toast.expire(msg) && !m.blocked() {
+			// the failure can not be retried once its toast is gone
+			m.failure = nil
+		}
+	case messages.EventsFetched:
+		m.failure = nil
+		m.ready = true
+		m.setEvents(msg.Events)
+		m.DataUpdated = msg.Time
+		m.DataOrigin = msg.Origin
+		m.loading = false
+	case tea.KeyMsg:
+		switch keypress := msg.String(); keypress {
+		case "left", "h":
+			m.move(-1)
+		case "right", "l":
+			m.move(1)
+		case "up", "k":
+			m.move(-7)
+		case "down", "j":
+			m.move(7)
+		case "[", "pgup":
+			m.moveMonth(-1)
+		case "]", "pgdown":
+			m.moveMonth(1)
+		case "t":
+			m.day = today()
+			m.selected = 0
+		case "r", "f5":
+			if m.failure != nil && m.failure.Retry != nil {
+				retry := m.failure.Retry
+				m.loading = m.blocked()
+				m.failure = nil
+				return m, retry
+			}
+		case "tab":
+			if n := len(m.dayEvents()); n > 0 {
+				m.selected = (m.selected + 1) % n
+			}
+		case "enter":
+			if e, ok := m.selectedEvent(); ok {
+				m.cancel()
+				day := m.day
+				m.session.calendarDay = &day
+				return setupEventView(m.appCtx, e, m.provider, m.session)
+			}
+		case "backspace", "esc":
+			m.cancel()
+			m.session.calendarDay = nil
+			return initializeList(m.appCtx, m.provider, m.session)
+		case "q", "ctrl+c":
+			m.cancel()
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func initializeCalendar(appCtx context.Context, provider provider.Provider, s *session) (tea.Model, tea.Cmd) {
+	cv := newCalendarView(appCtx, provider, s)
+	return cv, cv.Init()
+}
+
+func (m CalendarView) Header() string {
+	title := fmt.Sprintf("%s | %s", constants.Title, m.day.Format("January 2006"))
+	if m.session.favouritesOnly {
+		title = fmt.Sprintf("%s | %s favourites", title, favouriteMarker)
+	}
+	return titleBoxStyle.Render(titleTextStyle.Render(title))
+}
+
+func (m CalendarView) GetUpdatedAt() string {
+	dataUpdated := m.DataUpdated.Format("2006-01-02 15:04:05")
+	return withOrigin(fmt.Sprintf("updated at %s", dataUpdated), m.DataOrigin)
+}
+
+// blocked checks if the events could not be loaded, there is nothing to show but the error
+func (m CalendarView) blocked() bool {
+	return m.failure != nil && !m.ready
+}
+
+func (m CalendarView) Footer() string {
+	uts := m.toast.render(m.GetUpdatedAt(), m.width/2-infoBoxStyle.GetHorizontalFrameSize()-footerStyle.GetHorizontalFrameSize())
+	padded := infoBoxStyle.Render(uts)
+	h := m.help.View(m.keyMap)
+	l := lipgloss.PlaceHorizontal(m.width/2, lipgloss.Left, h)
+	r := lipgloss.PlaceHorizontal(m.width/2, lipgloss.Right, padded)
+	return footerStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top, l, r))
+}
+
+// cellWidth divides the window into the seven days of the week
+func (m CalendarView) cellWidth() int {
+	return max(calendarMinCellWidth, (m.width-magicReduce)/7)
+}
+
+// cell renders the day, wide cells have the headlines and the narrow ones a marker for each event
+func (m CalendarView) cell(d time.Time, inMonth bool) string {
+	width := m.cellWidth()
+	textWidth := uint(width - 2)
+	events := m.days[dayKey(d)]
+
+	number := fmt.Sprint(d.Day())
+	if dayKey(d) == dayKey(today()) {
+		number = lipgloss.NewStyle().Underline(true).Render(number)
+	}
+	lines := []string{number}
+	if m.useWide {
+		for i, e := range events {
+			if i == wideCellHeadlines-1 && len(events) > wideCellHeadlines {
+				lines = append(lines, fmt.Sprintf("+%d more", len(events)-i))
+				break
+			}
+			lines = append(lines, truncate.StringWithTail(e.Headline, textWidth, ellipsis))
+		}
+	} else if len(events) > 0 {
+		lines = append(lines, truncate.String(strings.Repeat("•", len(events)), textWidth))
+	}
+
+	height := 2
+	if m.useWide {
+		height = wideCellHeadlines + 1
+	}
+	style := calendarCellStyle.Copy().Width(width)
+	switch {
+	case dayKey(d) == dayKey(m.day):
+		// the border takes the place of the padding
+		style = calendarSelectedStyle.Copy().Width(width - 1)
+	case !inMonth:
+		style = calendarOtherMonthStyle.Copy().Width(width)
+	}
+	return style.Height(height).Render(strings.Join(lines, "\n"))
+}
+
+// weekStart returns the Monday of the week of the day
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// monthWeeks returns the Mondays of the weeks which have days of the month of the day
+func monthWeeks(day time.Time) []time.Time {
+	month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	var weeks []time.Time
+	for d := weekStart(month); d.Before(month.AddDate(0, 1, 0)); d = d.AddDate(0, 0, 7) {
+		weeks = append(weeks, d)
+	}
+	return weeks
+}
+
+// grid renders the weeks of the selected month starting on Monday
+func (m CalendarView) grid() string {
+	weeks := monthWeeks(m.day)
+
+	var weekdays []string
+	for i := 0; i < 7; i++ {
+		name := weeks[0].AddDate(0, 0, i).Format("Mon")
+		weekdays = append(weekdays, calendarWeekdayStyle.Copy().Width(m.cellWidth()).Render(name))
+	}
+	rows := []string{lipgloss.JoinHorizontal(lipgloss.Top, weekdays...)}
+
+	for _, start := range weeks {
+		var week []string
+		for i := 0; i < 7; i++ {
+			d := start.AddDate(0, 0, i)
+			week = append(week, m.cell(d, d.Month() == m.day.Month()))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, week...))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// dayList lists the events of the selected day, the one opened with enter is marked
+func (m CalendarView) dayList() string {
+	events := m.dayEvents()
+	if len(events) == 0 {
+		return calendarDayListStyle.Copy().Foreground(subduedColor).Render(fmt.Sprintf("No gigs on %s", m.day.Format("Mon 2.1.2006")))
+	}
+	var lines []string
+	for i, e := range events {
+		line := e.Headline
+		if len(e.Venue) > 0 {
+			line = fmt.Sprintf("%s · %s", line, e.Venue)
+		}
+		if !e.InStock {
+			line = fmt.Sprintf("%s · sold out", line)
+		}
+		if m.unlisted[e.ID()] {
+			line = fmt.Sprintf("%s · %s", line, unlistedText)
+		}
+		if m.session.state.IsFavourite(e.ID()) {
+			line = favouriteStyle.Render(favouriteMarker) + " " + line
+		}
+		if i == m.selected {
+			line = lipgloss.NewStyle().Foreground(selectedColor).Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, truncate.StringWithTail(line, uint(max(m.width-magicReduce, 0)), ellipsis))
+	}
+	return calendarDayListStyle.Render(strings.Join(lines, "\n"))
+}
+
+func (m CalendarView) View() string {
+	header := m.Header()
+	footer := m.Footer()
+	if m.loading {
+		availableHeight := constants.WindowSize.Height - lipgloss.Height(header) - lipgloss.Height(footer)
+		p := lipgloss.Place(m.width, availableHeight, 0.5, 0.5, m.spinner.View())
+		return lipgloss.JoinVertical(lipgloss.Top, header, p, footer)
+	}
+	availableHeight := constants.WindowSize.Height - lipgloss.Height(header) - lipgloss.Height(footer)
+	if m.blocked() {
+		e := errorScreen(*m.failure, "esc list | q quit", m.width, availableHeight)
+		return lipgloss.JoinVertical(lipgloss.Top, header, e, footer)
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, m.grid(), m.dayList())
+	content = lipgloss.NewStyle().Height(max(availableHeight, 0)).Render(content)
+	return lipgloss.JoinVertical(lipgloss.Top, header, content, footer)
+}
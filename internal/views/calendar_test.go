@@ -0,0 +1,123 @@
+package views
+
+import (
+	"context"
+	"github.com/johannessarpola/lutakkols/internal/state"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, dates.Location)
+}
+
+func TestMoveMonth(t *testing.T) {
+	tests := []struct {
+		from   time.Time
+		months int
+		want   time.Time
+	}{
+		{date(2024, 1, 31), 1, date(2024, 2, 29)},
+		{date(2023, 1, 31), 1, date(2023, 2, 28)},
+		{date(2024, 3, 31), -1, date(2024, 2, 29)},
+		{date(2024, 12, 15), 1, date(2025, 1, 15)},
+		{date(2024, 1, 15), -1, date(2023, 12, 15)},
+	}
+	for _, tt := range tests {
+		m := CalendarView{day: tt.from, selected: 1}
+		m.moveMonth(tt.months)
+		if !m.day.Equal(tt.want) || m.selected != 0 {
+			t.Errorf("moving %d months from %s got %s, want %s", tt.months, tt.from, m.day, tt.want)
+		}
+	}
+}
+
+func TestMonthWeeks(t *testing.T) {
+	tests := []struct {
+		day   time.Time
+		first time.Time
+		weeks int
+	}{
+		// starts on Wednesday
+		{date(2024, 5, 17), date(2024, 4, 29), 5},
+		// starts on Sunday
+		{date(2024, 9, 1), date(2024, 8, 26), 6},
+		// starts on Monday and has four full weeks
+		{date(2021, 2, 28), date(2021, 2, 1), 4},
+	}
+	for _, tt := range tests {
+		weeks := monthWeeks(tt.day)
+		if len(weeks) != tt.weeks || !weeks[0].Equal(tt.first) {
+			t.Errorf("weeks of %s got %d from %s, want %d from %s", tt.day, len(weeks), weeks[0], tt.weeks, tt.first)
+		}
+		for _, w := range weeks {
+			if w.Weekday() != time.Monday {
+				t.Errorf("week of %s does not start on Monday: %s", tt.day, w)
+			}
+		}
+	}
+}
+
+func calendarTestEvents() []models.Event {
+	format := func(d time.Time) string {
+		return d.Format("2.1.2006")
+	}
+	return []models.Event{
+		{Id: "past", Order: 0, Headline: "Past", Date: format(today().AddDate(0, 0, -5))},
+		{Id: "later", Order: 1, Headline: "Later", Date: format(today().AddDate(0, 0, 10))},
+		{Id: "next", Order: 2, Headline: "Next", Date: format(today().AddDate(0, 0, 3))},
+		{Id: "undated", Order: 3, Headline: "Undated", Date: "TBA"},
+	}
+}
+
+func TestSetEvents(t *testing.T) {
+	s := newSession(state.New(""))
+	m := newCalendarView(context.Background(), nil, s)
+	m.setEvents(calendarTestEvents())
+
+	if !m.day.Equal(today().AddDate(0, 0, 3)) {
+		t.Errorf("expected the calendar to start from the next gig, got %s", m.day)
+	}
+	if e, ok := m.selectedEvent(); !ok || e.ID() != "next" {
+		t.Errorf("expected the next gig to be selected, got %v", e)
+	}
+	n := 0
+	for _, es := range m.days {
+		n += len(es)
+	}
+	if n != 3 {
+		t.Errorf("expected the dated events in the calendar, got %d", n)
+	}
+
+	// returning from an event keeps the day
+	day := today().AddDate(0, 0, -5)
+	s.calendarDay = &day
+	m.setEvents(calendarTestEvents())
+	if !m.day.Equal(day) {
+		t.Errorf("expected the day to be restored, got %s", m.day)
+	}
+}
+
+func TestSetEventsUnlistedFavourites(t *testing.T) {
+	s := newSession(state.New(""))
+	events := calendarTestEvents()
+	gone := models.Event{Id: "gone", Headline: "Gone", Date: today().AddDate(0, 0, 1).Format("2.1.2006")}
+	s.state.ToggleFavourite(gone)
+	s.state.ToggleFavourite(events[1])
+	s.favouritesOnly = true
+
+	m := newCalendarView(context.Background(), nil, s)
+	m.setEvents(events)
+
+	if es := m.days[dayKey(today().AddDate(0, 0, 1))]; len(es) != 1 || es[0].ID() != "gone" || !m.unlisted["gone"] {
+		t.Errorf("expected the unlisted favourite in the calendar, got %v", es)
+	}
+	if es := m.days[dayKey(today().AddDate(0, 0, 10))]; len(es) != 1 || m.unlisted["later"] {
+		t.Errorf("expected the listed favourite in the calendar, got %v", es)
+	}
+	if es := m.days[dayKey(today().AddDate(0, 0, 3))]; len(es) != 0 {
+		t.Errorf("expected only the favourites in the calendar, got %v", es)
+	}
+}
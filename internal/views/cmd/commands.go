@@ -1,37 +1,99 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/johannessarpola/lutakkols/internal/browser"
 	"github.com/johannessarpola/lutakkols/internal/views/messages"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
 	"github.com/johannessarpola/lutakkols/pkg/api/options"
 	"github.com/johannessarpola/lutakkols/pkg/api/provider"
+	"github.com/johannessarpola/lutakkols/pkg/export"
 	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/workset"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
 )
 
-func GetDetails(eventID string, eventURL string, provider provider.Provider, opts ...options.ProviderOption) tea.Cmd {
+// cancelled checks if the error is because the view which started the command is gone
+func cancelled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// failed logs the error and returns it as a message, retry runs the failed command again
+func failed(action string, err error, retry tea.Cmd) tea.Msg {
+	logger.Log.Errorf("Err %s: %s", action, err.Error())
+	return messages.Failed{Action: action, Err: err, Retry: retry}
+}
+
+func GetDetails(ctx context.Context, eventID string, eventURL string, provider provider.Provider, opts ...options.ProviderOption) tea.Cmd {
 	return func() tea.Msg {
 		logger.Log.Debugf("getting description for %s from provider", eventURL)
-		eventDetails, err := provider.GetDetails(eventID, eventURL, opts...)
+		eventDetails, err := provider.GetDetails(ctx, eventID, eventURL, opts...)
+		if cancelled(err) {
+			logger.Log.Debugf("getting description for %s cancelled", eventURL)
+			return nil
+		}
 		if err != nil {
-			return err
+			return failed("getting details", err, GetDetails(ctx, eventID, eventURL, provider, opts...))
 		}
 		return messages.EventDescriptionFetched{Details: eventDetails, ProviderOptions: opts}
 	}
 }
 
-func GetAscii(eventID string, imageURL string, provider provider.Provider, opts ...options.ProviderOption) tea.Cmd {
+func GetAscii(ctx context.Context, eventID string, imageURL string, provider provider.Provider, opts ...options.ProviderOption) tea.Cmd {
 	return func() tea.Msg {
 		logger.Log.Debugf("getting ascii with url %s from provider", imageURL)
-		eventAscii, err := provider.GetAscii(eventID, imageURL, opts...)
+		eventAscii, err := provider.GetAscii(ctx, eventID, imageURL, opts...)
+		if cancelled(err) {
+			logger.Log.Debugf("getting ascii with url %s cancelled", imageURL)
+			return nil
+		}
 		if err != nil {
-			return err
+			return failed("getting the image", err, GetAscii(ctx, eventID, imageURL, provider, opts...))
 		}
 		return messages.EventAsciiFetched{Ascii: eventAscii.Ascii}
 	}
 }
 
-func GetEvents(provider provider.Provider, opts ...options.ProviderOption) tea.Cmd {
+// detailsWorkers is how many details GetAllDetails fetches at a time
+const detailsWorkers = 4
+
+// detailsTimeout is how long fetching the details of a single event can take
+const detailsTimeout = 20 * time.Second
+
+// GetAllDetails gets the details of the events a few at a time, failures are logged and the event is left out
+func GetAllDetails(ctx context.Context, events []models.Event, provider provider.Provider) tea.Cmd {
+	return func() tea.Msg {
+		tasks := make([]workset.Task[models.EventDetails], len(events))
+		for i, e := range events {
+			tasks[i] = func() (models.EventDetails, error) {
+				logger.Log.Debugf("getting description for %s from provider", e.EventLink)
+				return provider.GetDetails(ctx, e.ID(), e.EventLink)
+			}
+		}
+		results := workset.NewWorkSet(tasks, detailsWorkers, detailsTimeout).Collect()
+		if cancelled(ctx.Err()) {
+			logger.Log.Debugf("getting descriptions cancelled")
+			return nil
+		}
+
+		var rs []models.EventDetails
+		for _, r := range results {
+			if r.Error != nil {
+				logger.Log.Errorf("Err getting description for %s: %s", events[r.Index].EventLink, r.Error.Error())
+				continue
+			}
+			rs = append(rs, r.Value)
+		}
+		return messages.DetailsFetched{Details: rs}
+	}
+}
+
+func GetEvents(ctx context.Context, provider provider.Provider, opts ...options.ProviderOption) tea.Cmd {
 
 	return func() tea.Msg {
 		var (
@@ -39,11 +101,49 @@ func GetEvents(provider provider.Provider, opts ...options.ProviderOption) tea.C
 			err    error
 		)
 		logger.Log.Debugf("getting events from provider")
-		events, err = provider.GetEvents(opts...)
+		events, err = provider.GetEvents(ctx, opts...)
+		if cancelled(err) {
+			logger.Log.Debugf("getting events cancelled")
+			return nil
+		}
+
+		if err != nil {
+			return failed("getting events", err, GetEvents(ctx, provider, opts...))
+		}
+		return messages.EventsFetched{Events: events.Events, Time: events.UpdatedAt, Origin: events.Origin}
+	}
+}
 
+// ExportCalendar writes the event with its details as an iCalendar file into the path
+func ExportCalendar(event models.Event, details models.EventDetails, path string) tea.Cmd {
+	return func() tea.Msg {
+		logger.Log.Debugf("exporting %s into %s", event.ID(), path)
+		retry := ExportCalendar(event, details, path)
+		abs, err := filepath.Abs(path)
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(abs), os.ModePerm)
+		}
 		if err != nil {
-			return err
+			return failed("exporting the calendar", err, retry)
+		}
+		items := []export.Item{{Event: event, Details: &details}}
+		err = export.WriteFile(abs, func(w io.Writer) error {
+			return export.WriteICS(w, items, time.Now())
+		})
+		if err != nil {
+			return failed("exporting the calendar", err, retry)
+		}
+		return messages.CalendarExported{Path: abs}
+	}
+}
+
+// OpenBrowser opens the url in the default browser
+func OpenBrowser(url string) tea.Cmd {
+	return func() tea.Msg {
+		logger.Log.Debugf("opening %s in browser", url)
+		if err := browser.Open(url); err != nil {
+			return failed("opening the browser", err, OpenBrowser(url))
 		}
-		return messages.EventsFetched{Events: events.Events, Time: events.UpdatedAt}
+		return nil
 	}
 }
@@ -1,10 +1,29 @@
 package views
 
 import (
+	"fmt"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"io"
+	"unicode/utf8"
 )
 
+const ellipsis = "…"
+
+const favouriteMarker = "★"
+
+var (
+	favouriteStyle     = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#d19a0a", Dark: "#f5d742"})
+	sectionHeaderStyle = lipgloss.NewStyle().Bold(true).Foreground(subduedColor).Padding(1, 0, 0, 2)
+)
+
+// eventDelegate renders the events like the default delegate but highlights the filter matches in the description
+// as well as in the title and marks the favourites, section headers are rendered in place of an event
+type eventDelegate struct {
+	list.DefaultDelegate
+}
+
 // customizedDelegate customizes render properties of the default deleagete
 func customizedDelegate() list.ItemDelegate {
 	d := list.NewDefaultDelegate()
@@ -18,5 +37,71 @@ func customizedDelegate() list.ItemDelegate {
 	d.Styles.SelectedDesc = d.Styles.SelectedTitle.Copy().
 		Foreground(lipgloss.AdaptiveColor{Light: "#2e2eb0", Dark: "#d1780a"})
 
-	return d
+	d.Styles.FilterMatch = lipgloss.NewStyle().Underline(true).Bold(true)
+
+	return eventDelegate{DefaultDelegate: d}
+}
+
+func (d eventDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if h, ok := item.(sectionHeader); ok {
+		_, _ = fmt.Fprint(w, sectionHeaderStyle.Render(h.title))
+		return
+	}
+	i, ok := item.(list.DefaultItem)
+	if !ok || m.Width() <= 0 {
+		return
+	}
+	s := &d.Styles
+
+	textWidth := uint(m.Width() - s.NormalTitle.GetPaddingLeft() - s.NormalTitle.GetPaddingRight())
+	marker := ""
+	if ei, ok := item.(EventViewListItem); ok && ei.Favourite {
+		marker = favouriteStyle.Render(favouriteMarker) + " "
+	}
+	title := truncate.StringWithTail(i.Title(), textWidth-uint(lipgloss.Width(marker)), ellipsis)
+	desc := truncate.StringWithTail(i.Description(), textWidth, ellipsis)
+
+	var (
+		isSelected  = index == m.Index()
+		emptyFilter = m.FilterState() == list.Filtering && m.FilterValue() == ""
+		isFiltered  = m.FilterState() == list.Filtering || m.FilterState() == list.FilterApplied
+	)
+
+	// the matches are offsets into the filter value which starts with the title and the description
+	var titleMatches, descMatches []int
+	if isFiltered {
+		descStart := utf8.RuneCountInString(i.Title()) + utf8.RuneCountInString(fieldSeparator)
+		for _, idx := range m.MatchesForItem(index) {
+			if idx < descStart {
+				titleMatches = append(titleMatches, idx)
+			} else {
+				descMatches = append(descMatches, idx-descStart)
+			}
+		}
+	}
+	highlight := func(text string, style lipgloss.Style, matches []int) string {
+		if !isFiltered || len(matches) == 0 {
+			return text
+		}
+		unmatched := style.Inline(true)
+		return lipgloss.StyleRunes(text, matches, unmatched.Copy().Inherit(s.FilterMatch), unmatched)
+	}
+
+	switch {
+	case emptyFilter:
+		title = s.DimmedTitle.Render(marker + title)
+		desc = s.DimmedDesc.Render(desc)
+	case isSelected && m.FilterState() != list.Filtering:
+		title = s.SelectedTitle.Render(marker + highlight(title, s.SelectedTitle, titleMatches))
+		desc = s.SelectedDesc.Render(highlight(desc, s.SelectedDesc, descMatches))
+	default:
+		title = s.NormalTitle.Render(marker + highlight(title, s.NormalTitle, titleMatches))
+		desc = s.NormalDesc.Render(highlight(desc, s.NormalDesc, descMatches))
+	}
+
+	if d.ShowDescription {
+		_, _ = fmt.Fprintf(w, "%s\n%s", title, desc)
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s", title)
 }
@@ -1,11 +1,12 @@
 package views
 
 import (
+	"context"
 	"fmt"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/johannessarpola/lutakkols/internal/browser"
+	"github.com/johannessarpola/lutakkols/internal/state"
 	"github.com/johannessarpola/lutakkols/internal/views/cmd"
 	"github.com/johannessarpola/lutakkols/internal/views/constants"
 	"github.com/johannessarpola/lutakkols/internal/views/help"
@@ -15,6 +16,7 @@ import (
 	"github.com/johannessarpola/lutakkols/pkg/api/options"
 	"github.com/johannessarpola/lutakkols/pkg/api/provider"
 	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -31,7 +33,14 @@ type EventViev struct {
 	useWide     bool
 	eventLink   string
 	eventID     string
+	event       models.Event
+	toast       toast
+	failure     *messages.Failed // the last failure which r retries until its toast expires
 	provider    provider.Provider
+	ctx         context.Context
+	cancel      context.CancelFunc
+	appCtx      context.Context
+	session     *session
 	loading     bool
 	loadStarted time.Time
 	DataUpdated time.Time
@@ -42,12 +51,26 @@ func (m EventViev) Init() tea.Cmd {
 }
 
 func viewTitle(event models.Event) string {
+	if len(event.Venue) > 0 {
+		return fmt.Sprintf("%s | %s | %s", event.Headline, event.Date, event.Venue)
+	}
 	return fmt.Sprintf("%s | %s", event.Headline, event.Date)
 }
 
-func InitEventView(event models.Event, provider provider.Provider) EventViev {
+// InitEventView initializes the view, pending fetches of the view are cancelled when leaving it or when appCtx is done.
+// The favourites are kept in st.
+func InitEventView(appCtx context.Context, event models.Event, provider provider.Provider, st *state.State) EventViev {
+	return initEventView(appCtx, event, provider, newSession(st))
+}
+
+func initEventView(appCtx context.Context, event models.Event, provider provider.Provider, s *session) EventViev {
+	ctx, cancel := context.WithCancel(appCtx)
 
 	ev := EventViev{
+		ctx:         ctx,
+		cancel:      cancel,
+		appCtx:      appCtx,
+		session:     s,
 		loadStarted: time.Now(),
 		DataUpdated: time.Now(),
 		spinner:     newSpinner(),
@@ -57,9 +80,10 @@ func InitEventView(event models.Event, provider provider.Provider) EventViev {
 		eventLink:   event.EventURL(),
 		provider:    provider,
 		eventID:     event.ID(),
+		event:       event,
 		loading:     true,
 		help:        help.New(),
-		keyMap:      EventViewKeymap{},
+		keyMap:      EventViewKeymap{ExportDir: s.exportDir},
 	}
 
 	configureView(constants.WindowSize, &ev)
@@ -145,7 +169,8 @@ func (m EventViev) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case messages.EventDescriptionFetched:
 		m.details = msg.Details
-		gaCmd := cmd.GetAscii(msg.Details.EventID, msg.Details.ImageLink, m.provider, msg.ProviderOptions...)
+		m.session.details[m.eventID] = msg.Details
+		gaCmd := cmd.GetAscii(m.ctx, msg.Details.EventID, msg.Details.ImageLink, m.provider, msg.ProviderOptions...)
 		cs = append(cs, gaCmd)
 	case messages.EventAsciiFetched:
 		m.ascii = msg.Ascii
@@ -153,6 +178,23 @@ func (m EventViev) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return messages.FetchesDone{}
 		}
 		cs = append(cs, doneCmd)
+	case messages.CalendarExported:
+		cs = append(cs, m.toast.show(fmt.Sprintf("exported %s", msg.Path), false))
+	case messages.Failed:
+		m.failure = &msg
+		if m.loading {
+			// the view shows what was loaded before the failure, or the error screen if the details are missing
+			m.loading = false
+			m.DataUpdated = time.Now()
+		}
+		if !m.blocked() {
+			cs = append(cs, m.toast.showFailure(msg))
+		}
+	case toastExpired:
+		if m.toast.expire(msg) && !m.blocked() {
+			// the failure can not be retried once its toast is gone
+			m.failure = nil
+		}
 	case messages.FetchesDone:
 		m.loading = false
 		m.DataUpdated = time.Now()
@@ -164,11 +206,29 @@ func (m EventViev) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch keypress := msg.String(); keypress {
 		case "g", "right":
-			err := browser.Open(m.eventLink)
-			if err != nil {
-				logger.Log.Errorf("Err opening browser: %s", err.Error())
+			cs = append(cs, cmd.OpenBrowser(m.eventLink))
+		case "c":
+			if m.loading {
+				break
+			}
+			if len(m.details.EventID) == 0 {
+				cs = append(cs, m.toast.show("the details are not loaded, nothing to export", true))
+				break
+			}
+			cs = append(cs, cmd.ExportCalendar(m.event, m.details, filepath.Join(m.session.exportDir, m.event.ID()+".ics")))
+		case "s":
+			if m.session.toggleFavourite(m.event) {
+				cs = append(cs, m.toast.show("added to favourites", false))
+			} else {
+				cs = append(cs, m.toast.show("removed from favourites", false))
 			}
 		case "r", "f5":
+			if m.failure != nil && m.failure.Retry != nil {
+				m.loading = m.blocked()
+				cs = append(cs, m.failure.Retry)
+				m.failure = nil
+				break
+			}
 			if m.DataUpdated.Before(time.Now().Add(-30 * time.Second)) {
 				m.loading = true
 				cs = append(cs, m.Refresh())
@@ -176,9 +236,14 @@ func (m EventViev) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				logger.Log.Debug("ignoring refresh")
 			}
 		case "q", "ctrl+c":
+			m.cancel()
 			return m, tea.Quit
 		case "backspace", "left":
-			return initializeList(m.provider)
+			m.cancel()
+			if m.session.calendarDay != nil {
+				return initializeCalendar(m.appCtx, m.provider, m.session)
+			}
+			return initializeList(m.appCtx, m.provider, m.session)
 		}
 	default:
 		return m, nil
@@ -191,7 +256,16 @@ func (m EventViev) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cs...)
 }
 
+// blocked checks if the details could not be loaded, there is nothing to show but the error
+func (m EventViev) blocked() bool {
+	return m.failure != nil && len(m.details.EventID) == 0
+}
+
 func (m EventViev) View() string {
+	if m.blocked() {
+		e := errorScreen(*m.failure, "backspace back | q quit", m.viewport.Width, m.viewport.Height)
+		return fmt.Sprintf("%s\n%s\n%s", m.headerView(), e, m.footerView())
+	}
 	if m.loading {
 		w := m.viewport.Width
 		h := m.viewport.Height
@@ -202,26 +276,30 @@ func (m EventViev) View() string {
 }
 
 func (m EventViev) headerView() string {
-	title := singleTitleStyle.Render(m.title)
+	title := m.title
+	if m.session.state.IsFavourite(m.eventID) {
+		title = favouriteStyle.Render(favouriteMarker) + " " + title
+	}
+	title = singleTitleStyle.Render(title)
 	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
 }
 
 func (m EventViev) GetUpdatedAt() string {
 	dataUpdated := m.details.UpdatedAt.Format("2006-01-02 15:04:05")
-	return fmt.Sprintf("updated at %s", dataUpdated)
+	return withOrigin(fmt.Sprintf("updated at %s", dataUpdated), m.details.Origin)
 }
 
 func (m EventViev) footerView() string {
-	uts := updatedAtStyle.Render(m.GetUpdatedAt())
+	w := m.viewport.Width
+
 	scrollPercent := fmt.Sprintf("%3.f%% ", m.viewport.ScrollPercent()*100)
+	uts := m.toast.render(m.GetUpdatedAt(), w/2-lipgloss.Width(scrollPercent)-infoBoxStyle.GetHorizontalFrameSize()-footerStyle.GetHorizontalFrameSize())
 	contentBlock := lipgloss.JoinHorizontal(lipgloss.Top, scrollPercent, uts)
 
 	infoBox := infoBoxStyle.Render(contentBlock)
 	hp := m.help.View(m.keyMap)
 
-	w := m.viewport.Width
-
 	r := lipgloss.PlaceHorizontal(w/2, lipgloss.Right, infoBox)
 	l := lipgloss.PlaceHorizontal(w/2, lipgloss.Left, hp)
 	return footerStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top, l, r))
@@ -229,13 +307,13 @@ func (m EventViev) footerView() string {
 
 func (m EventViev) Refresh() tea.Cmd {
 	// chains ot ascii fetch as well
-	updateDetails := cmd.GetDetails(m.eventID, m.eventLink, m.provider, options.SkipCache)
+	updateDetails := cmd.GetDetails(m.ctx, m.eventID, m.eventLink, m.provider, options.SkipCache)
 	return updateDetails
 }
 
-func setupEventView(event models.Event, provider provider.Provider) (tea.Model, tea.Cmd) {
-	eventView := InitEventView(event, provider)
-	getDetailsCmd := cmd.GetDetails(event.ID(), event.EventLink, provider)
+func setupEventView(appCtx context.Context, event models.Event, provider provider.Provider, s *session) (tea.Model, tea.Cmd) {
+	eventView := initEventView(appCtx, event, provider, s)
+	getDetailsCmd := cmd.GetDetails(eventView.ctx, event.ID(), event.EventLink, provider)
 	_, updateCmd := eventView.Update(constants.WindowSize)
 	return eventView, tea.Batch(eventView.spinner.Tick, updateCmd, getDetailsCmd)
 }
@@ -1,12 +1,13 @@
 package views
 
 import (
+	"context"
 	"fmt"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/johannessarpola/lutakkols/internal/browser"
+	"github.com/johannessarpola/lutakkols/internal/state"
 	"github.com/johannessarpola/lutakkols/internal/views/cmd"
 	"github.com/johannessarpola/lutakkols/internal/views/constants"
 	"github.com/johannessarpola/lutakkols/internal/views/help"
@@ -31,16 +32,54 @@ type EventList struct {
 	spinner     spinner.Model
 	Quitting    bool
 	provider    provider.Provider
+	ctx         context.Context
+	cancel      context.CancelFunc
+	appCtx      context.Context
+	session     *session
+	ready       bool
+	events      []models.Event
+	unlisted    []models.Event
+	pricing     bool // the details are being loaded for sorting by price
+	toast       toast
+	failure     *messages.Failed // the last failure which r retries until its toast expires
 	DataUpdated time.Time
+	DataOrigin  models.Origin
 	WindowSize  WindowSize
 }
 
-func massageItems(events []models.Event) []list.Item {
-	items := make([]list.Item, len(events))
-	for i, v := range events {
-		items[i] = EventViewListItem{
-			Event: v,
+func newListItem(e models.Event, s *session, unlisted bool) EventViewListItem {
+	return EventViewListItem{
+		Event:     e,
+		Details:   s.loadedDetails(e.ID()),
+		Favourite: s.state.IsFavourite(e.ID()),
+		Unlisted:  unlisted,
+	}
+}
+
+// massageItems turns the events into list items sorted and grouped in the mode of the session, favourites which are
+// no longer listed are kept at the end
+func massageItems(events []models.Event, unlisted []models.Event, s *session) []list.Item {
+	shown := func(events []models.Event) []models.Event {
+		if !s.favouritesOnly {
+			return events
 		}
+		var rs []models.Event
+		for _, e := range events {
+			if s.state.IsFavourite(e.ID()) {
+				rs = append(rs, e)
+			}
+		}
+		return rs
+	}
+
+	gm := s.groupMode()
+	items := sortItems(shown(events), s, s.sortMode(), gm)
+	unlisted = shown(unlisted)
+	if len(unlisted) > 0 && gm != groupNone {
+		items = append(items, sectionHeader{title: "No longer listed"})
+	}
+	for _, e := range unlisted {
+		items = append(items, newListItem(e, s, true))
 	}
 	return items
 }
@@ -53,12 +92,20 @@ func setupKeybinds(m *list.Model) {
 		return []key.Binding{
 			GoToEventPage(),
 			RefreshPage(),
+			ToggleFavourite(),
+			SortMode(),
+			OpenCalendar(),
 		}
 	}
 	m.AdditionalFullHelpKeys = func() []key.Binding {
 		return []key.Binding{
 			GoToEventPage(),
 			RefreshPage(),
+			ToggleFavourite(),
+			FavouritesOnly(),
+			SortMode(),
+			GroupMode(),
+			OpenCalendar(),
 		}
 	}
 }
@@ -74,7 +121,9 @@ func setupListModel(slm *list.Model) {
 	slm.SetShowHelp(false) // TODO customize sometime
 	slm.SetShowStatusBar(false)
 	slm.SetShowTitle(false)
-	slm.SetFilteringEnabled(false) // TODO Fix sometime
+	slm.SetFilteringEnabled(true)
+	slm.Filter = fuzzyFilter
+	slm.FilterInput.Prompt = "/ "
 	slm.Styles.PaginationStyle = paginationStyle
 	slm.Styles.HelpStyle = footerStyle
 }
@@ -97,9 +146,26 @@ func emptyList() list.Model {
 	return slm
 }
 
-func NewEventsList(provider provider.Provider) EventList {
+// NewEventsList initializes the list, pending fetches are cancelled when leaving the list or when appCtx is done.
+// The favourites are kept in st.
+func NewEventsList(appCtx context.Context, provider provider.Provider, st *state.State) EventList {
+	return newEventsList(appCtx, provider, newSession(st))
+}
+
+// WithExportDir sets the directory the events are exported into, the working directory is used by default
+func (m EventList) WithExportDir(dir string) EventList {
+	m.session.exportDir = dir
+	return m
+}
+
+func newEventsList(appCtx context.Context, provider provider.Provider, s *session) EventList {
+	ctx, cancel := context.WithCancel(appCtx)
 
 	return EventList{
+		ctx:         ctx,
+		cancel:      cancel,
+		appCtx:      appCtx,
+		session:     s,
 		Quitting:    false,
 		loading:     true,
 		spinner:     newSpinner(),
@@ -111,7 +177,7 @@ func NewEventsList(provider provider.Provider) EventList {
 }
 
 func (m EventList) Init() tea.Cmd {
-	ge := cmd.GetEvents(m.provider)
+	ge := cmd.GetEvents(m.ctx, m.provider)
 	return tea.Sequence(m.spinner.Tick, ge)
 }
 
@@ -136,41 +202,165 @@ func (m EventList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			h: msg.Height,
 		}
 		return m, c
+	case messages.Failed:
+		m.loading = false
+		m.failure = &msg
+		if !m.ready {
+			// there is nothing to show so the error screen is shown instead of the list
+			return m, nil
+		}
+		return m, m.toast.showFailure(msg)
+	case toastExpired:
+		if m.toast.expire(msg) && m.ready {
+			// the failure can not be retried once its toast is gone
+			m.failure = nil
+		}
+		return m, nil
 	case messages.EventsFetched:
-		i := massageItems(msg.Events)
+		m.failure = nil
+		m.events = msg.Events
 		m.DataUpdated = msg.Time
-		m.list = m.configureList(i)
+		m.DataOrigin = msg.Origin
 		m.loading = false
+		if m.ready {
+			// keeps the applied filter over refreshes
+			return m, m.updateItems()
+		}
+		m.reconcile()
+		m.list = m.configureList(massageItems(m.events, m.unlisted, m.session))
+		m.skipHeaders(0)
+		m.ready = true
+		return m, nil
+	case messages.DetailsFetched:
+		for _, ed := range msg.Details {
+			m.session.details[ed.EventID] = ed
+		}
+		m.pricing = false
+		return m, m.updateItems()
 	case tea.KeyMsg:
+		if m.list.SettingFilter() {
+			// the keys are typed into the filter
+			break
+		}
 		switch keypress := msg.String(); keypress {
 		case "g":
-			selectedEvent := m.list.SelectedItem().(EventViewListItem)
-			err := browser.Open(selectedEvent.Event.EventLink)
-			if err != nil {
-				panic(err)
+			selectedEvent, ok := m.list.SelectedItem().(EventViewListItem)
+			if !ok {
+				break
+			}
+			return m, cmd.OpenBrowser(selectedEvent.Event.EventLink)
+		case "s":
+			selectedEvent, ok := m.list.SelectedItem().(EventViewListItem)
+			if !ok {
+				break
 			}
+			m.session.toggleFavourite(selectedEvent.Event)
+			return m, m.updateItems()
+		case "F":
+			m.session.favouritesOnly = !m.session.favouritesOnly
+			return m, m.updateItems()
+		case "o":
+			m.session.cycleSort()
+			return m, tea.Batch(m.updateItems(), m.loadPrices())
+		case "m":
+			m.session.cycleGroup()
+			return m, m.updateItems()
+		case "c":
+			m.cancel()
+			return initializeCalendar(m.appCtx, m.provider, m.session)
 		case "r", "f5":
+			if m.failure != nil && m.failure.Retry != nil {
+				retry := m.failure.Retry
+				m.failure = nil
+				m.loading = !m.ready
+				return m, retry
+			}
 			if m.DataUpdated.Before(time.Now().Add(-30 * time.Second)) {
 				m.loading = true
-				return m, cmd.GetEvents(m.provider, options.SkipCache)
+				return m, cmd.GetEvents(m.ctx, m.provider, options.SkipCache)
 			} else {
 				logger.Log.Debug("ignoring refresh")
 			}
 		case "q", "ctrl+c":
 			m.Quitting = true
+			m.cancel()
 			return m, tea.Quit
 		case "enter":
-			selectedEvent := m.list.SelectedItem().(EventViewListItem)
-			return setupEventView(selectedEvent.Event, m.provider)
+			selectedEvent, ok := m.list.SelectedItem().(EventViewListItem)
+			if !ok {
+				break
+			}
+			m.cancel()
+			return setupEventView(m.appCtx, selectedEvent.Event, m.provider, m.session)
 		}
 	}
 
+	previous := m.list.Index()
 	m.list, c = m.list.Update(msg)
+	m.skipHeaders(previous)
 	return m, c
 }
 
-func initializeList(provider provider.Provider) (tea.Model, tea.Cmd) {
-	newList := NewEventsList(provider)
+// reconcile updates the favourites with the fetched events to find the ones which are no longer listed
+func (m *EventList) reconcile() {
+	unlisted, changed := m.session.state.Reconcile(m.events)
+	if changed {
+		m.session.saveState()
+	}
+	m.unlisted = unlisted
+}
+
+// updateItems replaces the items of the list after the events, the favourites or the mode have changed, the selected
+// event stays selected
+func (m *EventList) updateItems() tea.Cmd {
+	selected, _ := m.list.SelectedItem().(EventViewListItem)
+	m.reconcile()
+	c := m.list.SetItems(massageItems(m.events, m.unlisted, m.session))
+	m.list.Select(0)
+	for i, item := range m.list.VisibleItems() {
+		if ei, ok := item.(EventViewListItem); ok && ei.Event.ID() == selected.Event.ID() {
+			m.list.Select(i)
+			break
+		}
+	}
+	m.skipHeaders(0)
+	return c
+}
+
+// skipHeaders moves the cursor off a section header in the direction it was moving from the previous index
+func (m *EventList) skipHeaders(previous int) {
+	if _, ok := m.list.SelectedItem().(sectionHeader); !ok {
+		return
+	}
+	if m.list.Index() < previous {
+		m.list.CursorUp()
+	}
+	if _, ok := m.list.SelectedItem().(sectionHeader); ok {
+		m.list.CursorDown()
+	}
+}
+
+// loadPrices loads the details of the events which do not have them yet when sorting by price, it is run only when
+// the sorting is changed so that starting with the remembered price sorting does not fetch every event
+func (m *EventList) loadPrices() tea.Cmd {
+	if m.pricing || m.session.sortMode() != sortByPrice {
+		return nil
+	}
+	var missing []models.Event
+	for _, e := range m.events {
+		if m.session.loadedDetails(e.ID()) == nil {
+			missing = append(missing, e)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	m.pricing = true
+	return cmd.GetAllDetails(m.ctx, missing, m.provider)
+}
+
+func initializeList(appCtx context.Context, provider provider.Provider, s *session) (tea.Model, tea.Cmd) {
+	newList := newEventsList(appCtx, provider, s)
 	init := newList.Init()
 	tick := newList.spinner.Tick
 	_, update := newList.Update(constants.WindowSize)
@@ -179,7 +369,7 @@ func initializeList(provider provider.Provider) (tea.Model, tea.Cmd) {
 }
 
 func (m EventList) Footer() string {
-	uts := updatedAtStyle.Render(m.GetUpdatedAt())
+	uts := m.toast.render(m.GetUpdatedAt(), constants.WindowSize.Width/2-infoBoxStyle.GetHorizontalFrameSize()-footerStyle.GetHorizontalFrameSize())
 	padded := infoBoxStyle.Render(uts)
 	h := m.help.View(m.list)
 	l := lipgloss.PlaceHorizontal(constants.WindowSize.Width/2, lipgloss.Left, h)
@@ -190,11 +380,24 @@ func (m EventList) Footer() string {
 
 func (m EventList) GetUpdatedAt() string {
 	dataUpdated := m.DataUpdated.Format("2006-01-02 15:04:05")
-	return fmt.Sprintf("updated at %s", dataUpdated)
+	updated := withOrigin(fmt.Sprintf("updated at %s", dataUpdated), m.DataOrigin)
+	if m.pricing {
+		updated = fmt.Sprintf("loading prices | %s", updated)
+	}
+	if m.list.FilterState() != list.Unfiltered {
+		return fmt.Sprintf("%d/%d matching | %s", len(m.list.VisibleItems()), len(m.list.Items()), updated)
+	}
+	return updated
 }
 
 func (m EventList) Header() string {
-	title := constants.Title
+	title := fmt.Sprintf("%s | %s", constants.Title, m.session.sortMode())
+	if gm := m.session.groupMode(); gm != groupNone {
+		title = fmt.Sprintf("%s, per %s", title, gm)
+	}
+	if m.session.favouritesOnly {
+		title = fmt.Sprintf("%s | %s favourites", title, favouriteMarker)
+	}
 	r1 := titleTextStyle.Render(title)
 	return titleBoxStyle.Render(r1)
 }
@@ -210,6 +413,10 @@ func (m EventList) View() string {
 		p := lipgloss.Place(defaultWidth, availableHeight, 0.5, 0.5, m.spinner.View())
 		return lipgloss.JoinVertical(lipgloss.Top, header, p, footer)
 	}
+	if m.failure != nil && !m.ready {
+		e := errorScreen(*m.failure, "q quit", constants.WindowSize.Width, availableHeight)
+		return lipgloss.JoinVertical(lipgloss.Top, header, e, footer)
+	}
 
 	l := m.list.View()
 	return lipgloss.JoinVertical(lipgloss.Top, header, l, footer)
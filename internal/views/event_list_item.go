@@ -5,15 +5,28 @@ import (
 	"strings"
 )
 
+const unlistedText = "no longer listed"
+
 type EventViewListItem struct {
-	Event models.Event
+	Event     models.Event
+	Details   *models.EventDetails // nil until the details of the event have been loaded
+	Favourite bool
+	Unlisted  bool // favourite which the source does not list anymore
 }
 
 func (i EventViewListItem) Title() string { return i.Event.Headline }
 func (i EventViewListItem) Description() string {
 	sb := strings.Builder{}
+	if i.Unlisted {
+		sb.WriteString(unlistedText)
+		sb.WriteString(" · ")
+	}
 	sb.WriteString(i.Event.Date)
 	sb.WriteString(" · ")
+	if len(i.Event.Venue) > 0 {
+		sb.WriteString(i.Event.Venue)
+		sb.WriteString(" · ")
+	}
 
 	for _, bp := range i.Event.BulletPoints {
 		sb.WriteString(bp)
@@ -22,4 +35,14 @@ func (i EventViewListItem) Description() string {
 
 	return sb.String()
 }
-func (i EventViewListItem) FilterValue() string { return i.Event.Headline }
+
+// FilterValue starts with the title and the description so that the delegate can highlight the matches in them,
+// the weekday and the loaded details are searchable as well
+func (i EventViewListItem) FilterValue() string {
+	fields := []string{i.Title(), i.Description(), i.Event.Weekday}
+	if i.Details != nil {
+		fields = append(fields, i.Details.PlayTimes...)
+		fields = append(fields, i.Details.Description...)
+	}
+	return strings.Join(fields, fieldSeparator)
+}
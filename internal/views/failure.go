@@ -0,0 +1,106 @@
+package views
+
+import (
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/johannessarpola/lutakkols/internal/views/messages"
+	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/muesli/reflow/truncate"
+	"time"
+)
+
+const toastDuration = 4 * time.Second
+
+var (
+	errorColor      = lipgloss.AdaptiveColor{Light: "#c0392b", Dark: "#ff6b6b"}
+	toastStyle      = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#2e2eb0", Dark: "#d1780a"})
+	errorToastStyle = lipgloss.NewStyle().Foreground(errorColor)
+	errorTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(errorColor)
+	errorTextStyle  = lipgloss.NewStyle().MarginTop(1)
+	errorHintStyle  = lipgloss.NewStyle().MarginTop(1).Foreground(subduedColor)
+)
+
+// toastSeq identifies the toasts so that an expiring toast does not hide a newer one, also in another view
+var toastSeq int
+
+// toast is a notification shown in the footer for a while without blocking the view
+type toast struct {
+	text      string
+	isError   bool
+	id        int
+	failureID int // the toast of the last failure, r retries the failure until it expires
+}
+
+type toastExpired struct {
+	id int
+}
+
+// show replaces the current toast, the returned command hides it after a while
+func (t *toast) show(text string, isError bool) tea.Cmd {
+	toastSeq++
+	t.id = toastSeq
+	t.text = text
+	t.isError = isError
+	id := t.id
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpired{id: id}
+	})
+}
+
+// showFailure shows the failure as an error toast
+func (t *toast) showFailure(f messages.Failed) tea.Cmd {
+	text := failureText(f)
+	if f.Retry != nil {
+		text = fmt.Sprintf("%s | r to retry", text)
+	}
+	cmd := t.show(text, true)
+	t.failureID = t.id
+	return cmd
+}
+
+// expire hides the toast if it is still shown, returns true when the toast of the last failure has expired
+func (t *toast) expire(msg toastExpired) bool {
+	if msg.id == t.id {
+		t.text = ""
+	}
+	return msg.id == t.failureID
+}
+
+// render returns the toast truncated to the width, or the info when there is no toast
+func (t toast) render(info string, width int) string {
+	switch {
+	case len(t.text) == 0:
+		return updatedAtStyle.Render(info)
+	case t.isError:
+		return errorToastStyle.Render(truncate.StringWithTail(t.text, uint(max(width, 0)), ellipsis))
+	default:
+		return toastStyle.Render(truncate.StringWithTail(t.text, uint(max(width, 0)), ellipsis))
+	}
+}
+
+// describe explains the error, fetch errors are diagnosed and the others shown as they are
+func describe(err error) string {
+	if fetch.KindOf(err) == 0 {
+		return err.Error()
+	}
+	return fetch.Diagnose(err)
+}
+
+func failureText(f messages.Failed) string {
+	return fmt.Sprintf("%s failed: %s", f.Action, describe(f.Err))
+}
+
+// errorScreen is shown in place of a view which has nothing to show because of the failure, hint has the keys
+// to leave the view
+func errorScreen(f messages.Failed, hint string, width int, height int) string {
+	if f.Retry != nil {
+		hint = fmt.Sprintf("r retry | %s", hint)
+	}
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		errorTitleStyle.Render("Something went wrong"),
+		errorTextStyle.Render(failureText(f)),
+		errorHintStyle.Render(hint),
+	)
+	return lipgloss.Place(width, max(height, 0), 0.5, 0.5, content)
+}
@@ -0,0 +1,143 @@
+package views
+
+import (
+	"context"
+	"errors"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/johannessarpola/lutakkols/internal/state"
+	"github.com/johannessarpola/lutakkols/internal/views/messages"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToastExpire(t *testing.T) {
+	var tt toast
+	tt.show("first", false)
+	stale := tt.id
+	tt.show("second", false)
+
+	tt.expire(toastExpired{id: stale})
+	if tt.text != "second" {
+		t.Errorf("stale expiry hid the newer toast")
+	}
+	tt.expire(toastExpired{id: tt.id})
+	if len(tt.text) != 0 {
+		t.Errorf("expected the toast to be hidden, got %q", tt.text)
+	}
+}
+
+func TestEventListFailed(t *testing.T) {
+	retried := 0
+	failure := messages.Failed{
+		Action: "getting events",
+		Err:    errors.New("no such file"),
+		Retry: func() tea.Msg {
+			retried++
+			return messages.EventsFetched{Events: sortTestEvents(), Time: time.Now()}
+		},
+	}
+	var m tea.Model = NewEventsList(context.Background(), nil, state.New(""))
+
+	// before the events are loaded the error is shown in place of the list
+	m, _ = m.Update(failure)
+	el := m.(EventList)
+	if el.ready || el.failure == nil || len(el.toast.text) != 0 {
+		t.Fatalf("expected the error screen, got ready %t failure %v toast %q", el.ready, el.failure, el.toast.text)
+	}
+	if !strings.Contains(el.View(), "getting events failed: no such file") {
+		t.Errorf("expected the failure in the view:\n%s", el.View())
+	}
+
+	// r runs the retry of the failure
+	m, c := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if c == nil {
+		t.Fatalf("expected r to retry")
+	}
+	m, _ = m.Update(c())
+	el = m.(EventList)
+	if retried != 1 || !el.ready || el.failure != nil {
+		t.Fatalf("expected the retry to load the events, retried %d ready %t", retried, el.ready)
+	}
+
+	// after the events are loaded the error is a toast over the list
+	m, _ = m.Update(failure)
+	el = m.(EventList)
+	if el.failure == nil || !el.toast.isError || !strings.Contains(el.toast.text, "r to retry") {
+		t.Errorf("expected an error toast, got %q", el.toast.text)
+	}
+	if strings.Contains(el.View(), "Something went wrong") {
+		t.Errorf("expected the list to be shown with the toast")
+	}
+
+	// once the toast has expired r does not replay the failure anymore
+	m, _ = m.Update(toastExpired{id: el.toast.id})
+	if el = m.(EventList); el.failure != nil || len(el.toast.text) != 0 {
+		t.Fatalf("expected the failure to expire with its toast")
+	}
+	// the events were just loaded so r does not refresh either
+	if _, c = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}); c != nil {
+		t.Errorf("expected r not to retry the expired failure")
+	}
+}
+
+func TestFailureExpiresWithToast(t *testing.T) {
+	var tt toast
+	tt.showFailure(messages.Failed{Action: "getting details", Err: errors.New("timeout")})
+	failure := tt.id
+	tt.show("added to favourites", false)
+
+	if tt.expire(toastExpired{id: tt.id}) {
+		t.Errorf("expected another toast not to expire the failure")
+	}
+	if !tt.expire(toastExpired{id: failure}) {
+		t.Errorf("expected the failure to expire with its toast, also after it was replaced")
+	}
+
+	// the blocking failure of the event view is kept for the error screen
+	s := newSession(state.New(""))
+	var m tea.Model = initEventView(context.Background(), models.Event{Id: "band-one"}, nil, s)
+	m, _ = m.Update(messages.Failed{Action: "getting details", Err: errors.New("timeout")})
+	m, _ = m.Update(toastExpired{id: failure})
+	if ev := m.(EventViev); ev.failure == nil || !ev.blocked() {
+		t.Errorf("expected the error screen to keep the failure")
+	}
+}
+
+func TestEventViewExport(t *testing.T) {
+	dir := t.TempDir()
+	s := newSession(state.New(""))
+	s.exportDir = filepath.Join(dir, "exports")
+	event := models.Event{Id: "band-one", Headline: "Band One"}
+	var m tea.Model = initEventView(context.Background(), event, nil, s)
+	c := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")}
+
+	// the failed details block the export
+	m, _ = m.Update(messages.Failed{Action: "getting details", Err: errors.New("timeout")})
+	ev := m.(EventViev)
+	ev.loading = false
+	m, _ = ev.Update(c)
+	if ev = m.(EventViev); !strings.Contains(ev.toast.text, "not loaded") {
+		t.Errorf("expected the export to be refused, got toast %q", ev.toast.text)
+	}
+	if _, err := os.Stat(s.exportDir); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be exported, got: %v", err)
+	}
+
+	ev.failure = nil
+	ev.details = models.EventDetails{EventID: "band-one"}
+	_, cmd := ev.Update(c)
+	if cmd == nil {
+		t.Fatalf("expected the export to start")
+	}
+	msg, ok := cmd().(messages.CalendarExported)
+	if want := filepath.Join(s.exportDir, "band-one.ics"); !ok || msg.Path != want {
+		t.Fatalf("expected the export into %s, got %+v", want, msg)
+	}
+	if _, err := os.Stat(msg.Path); err != nil {
+		t.Errorf("the export was not written: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package views
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/sahilm/fuzzy"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// fieldSeparator separates the fields of the filter value, a word of the filter term is matched within a single field
+const fieldSeparator = "\n"
+
+// maxSpread limits how scattered the fuzzy match of a word can be relative to its length, otherwise short words
+// would match almost any long description
+const maxSpread = 3
+
+// fuzzyFilter is the filter of the event list, every word of the term has to match a field of the filter value
+// fuzzily. The ranks are ordered by the score of the matches and the matched indexes are rune offsets into the
+// filter value as expected by the delegate.
+func fuzzyFilter(term string, targets []string) []list.Rank {
+	words := strings.Fields(term)
+	if len(words) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		rank  list.Rank
+		score int
+	}
+	var rs []scored
+	for i, target := range targets {
+		fields := strings.Split(target, fieldSeparator)
+		score := 0
+		var matched []int
+		for _, w := range words {
+			s, indexes, ok := bestField(w, fields)
+			if !ok {
+				matched = nil
+				break
+			}
+			score += s
+			matched = append(matched, indexes...)
+		}
+		if matched == nil {
+			continue
+		}
+		slices.Sort(matched)
+		rs = append(rs, scored{rank: list.Rank{Index: i, MatchedIndexes: slices.Compact(matched)}, score: score})
+	}
+
+	slices.SortStableFunc(rs, func(a, b scored) int {
+		return b.score - a.score
+	})
+	ranks := make([]list.Rank, len(rs))
+	for i, r := range rs {
+		ranks[i] = r.rank
+	}
+	return ranks
+}
+
+// bestField finds the field with the best match of the word, the indexes are rune offsets into the joined fields
+func bestField(word string, fields []string) (int, []int, bool) {
+	var (
+		bestScore int
+		best      []int
+		found     bool
+		offset    int
+	)
+	limit := maxSpread * utf8.RuneCountInString(word)
+	for _, f := range fields {
+		matches := fuzzy.Find(word, []string{f})
+		if len(matches) == 1 {
+			m := matches[0]
+			indexes := runeIndexes(f, m.MatchedIndexes)
+			spread := indexes[len(indexes)-1] - indexes[0] + 1
+			if spread <= limit && (!found || m.Score > bestScore) {
+				bestScore, found = m.Score, true
+				best = best[:0]
+				for _, idx := range indexes {
+					best = append(best, offset+idx)
+				}
+			}
+		}
+		offset += utf8.RuneCountInString(f) + utf8.RuneCountInString(fieldSeparator)
+	}
+	return bestScore, best, found
+}
+
+// runeIndexes converts the byte offsets reported by the fuzzy matching into rune offsets
+func runeIndexes(s string, byteIndexes []int) []int {
+	rs := make([]int, len(byteIndexes))
+	for i, b := range byteIndexes {
+		rs[i] = utf8.RuneCountInString(s[:b])
+	}
+	return rs
+}
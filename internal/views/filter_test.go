@@ -0,0 +1,83 @@
+package views
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"slices"
+	"testing"
+)
+
+func testItems() []EventViewListItem {
+	return []EventViewListItem{
+		{Event: models.Event{Headline: "Music Concert", Date: "30.5.2024", BulletPoints: []string{"K18"}}},
+		{Event: models.Event{Headline: "Art Exhibition", Date: "1.6.2024", Venue: "Sali"}},
+		{
+			Event:   models.Event{Headline: "Älymystö", Date: "2.6.2024"},
+			Details: &models.EventDetails{Description: []string{"Kotimainen punk yhtye Jyväskylästä"}},
+		},
+	}
+}
+
+func filterTargets(items []EventViewListItem) []string {
+	targets := make([]string, len(items))
+	for i, item := range items {
+		targets[i] = item.FilterValue()
+	}
+	return targets
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	targets := filterTargets(testItems())
+
+	tests := []struct {
+		term string
+		want []int
+	}{
+		{"concert", []int{0}},
+		{"mscon", []int{0}},
+		{"art sali", []int{1}},
+		{"1.6", []int{1}},
+		{"punk", []int{2}},
+		{"jyvaskyla", nil},
+		{"jyväs", []int{2}},
+		{"k18 concert", []int{0}},
+		{"k18 art", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			var got []int
+			for _, r := range fuzzyFilter(tt.term, targets) {
+				got = append(got, r.Index)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFuzzyFilterScattered(t *testing.T) {
+	// the letters are in the description but too far apart to be a match
+	targets := []string{"Headline\nabcdefghijklmnopqrstuvwxyz"}
+	if ranks := fuzzyFilter("az", targets); len(ranks) != 0 {
+		t.Errorf("expected no match for a scattered term, got %v", ranks)
+	}
+}
+
+func TestFuzzyFilterRuneIndexes(t *testing.T) {
+	items := testItems()
+	ranks := fuzzyFilter("mys", filterTargets(items))
+	if len(ranks) != 1 || ranks[0].Index != 2 {
+		t.Fatalf("expected a match in the third item, got %v", ranks)
+	}
+	// Ä is two bytes but a single rune
+	if want := []int{3, 4, 5}; !slices.Equal(ranks[0].MatchedIndexes, want) {
+		t.Errorf("expected rune indexes %v, got %v", want, ranks[0].MatchedIndexes)
+	}
+
+	ranks = fuzzyFilter("sali", filterTargets(items))
+	descStart := len([]rune(items[1].Title())) + 1
+	if len(ranks) != 1 || ranks[0].MatchedIndexes[0] != descStart+len([]rune("1.6.2024 · ")) {
+		t.Errorf("expected the match to be offset into the description, got %v", ranks)
+	}
+}
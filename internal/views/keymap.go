@@ -3,6 +3,9 @@ package views
 import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 func Back() key.Binding {
@@ -26,6 +29,104 @@ func RefreshPage() key.Binding {
 	)
 }
 
+// ExportCalendar shows the directory the file is written into, the home directory is shortened
+func ExportCalendar(dir string) key.Binding {
+	if len(dir) == 0 {
+		dir = "."
+	}
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(dir, home+string(filepath.Separator)) {
+		dir = "~" + strings.TrimPrefix(dir, home)
+	}
+	return key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "export ics into "+dir),
+	)
+}
+
+func ToggleFavourite() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "favourite"),
+	)
+}
+
+func FavouritesOnly() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "favourites only"),
+	)
+}
+
+func SortMode() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "sort"),
+	)
+}
+
+func GroupMode() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "group by month/week"),
+	)
+}
+
+func OpenCalendar() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "calendar"),
+	)
+}
+
+func CalendarMove() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("up", "down", "left", "right", "h", "j", "k", "l"),
+		key.WithHelp("←↓↑→/hjkl", "day"),
+	)
+}
+
+func CalendarMonth() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("[", "]", "pgup", "pgdown"),
+		key.WithHelp("[/]", "month"),
+	)
+}
+
+func CalendarToday() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "today"),
+	)
+}
+
+func NextOfDay() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next gig of the day"),
+	)
+}
+
+func OpenEvent() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open"),
+	)
+}
+
+func CloseCalendar() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("backspace", "esc"),
+		key.WithHelp("esc", "list"),
+	)
+}
+
+func Quit() key.Binding {
+	return key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("q", "quit"),
+	)
+}
+
 func CursorUp() key.Binding {
 	return key.NewBinding(
 		key.WithKeys("up", "k"),
@@ -100,7 +201,10 @@ func eventListKeymap() list.KeyMap {
 	}
 }
 
-type EventViewKeymap struct{}
+// EventViewKeymap has the keys of the event view, the help shows the ExportDir the events are exported into
+type EventViewKeymap struct {
+	ExportDir string
+}
 
 func (m EventViewKeymap) ShortHelp() []key.Binding {
 	group := []key.Binding{
@@ -108,6 +212,8 @@ func (m EventViewKeymap) ShortHelp() []key.Binding {
 		CursorDown(),
 		RefreshPage(),
 		GoToEventPage(),
+		ExportCalendar(m.ExportDir),
+		ToggleFavourite(),
 		Back(),
 	}
 	return group
@@ -119,9 +225,37 @@ func (m EventViewKeymap) FullHelp() [][]key.Binding {
 		CursorDown(),
 		RefreshPage(),
 		GoToEventPage(),
+		ExportCalendar(m.ExportDir),
+		ToggleFavourite(),
 		Back(),
 	}
 	return [][]key.Binding{
 		group,
 	}
 }
+
+type CalendarKeymap struct{}
+
+func (m CalendarKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		CalendarMove(),
+		CalendarMonth(),
+		OpenEvent(),
+		CloseCalendar(),
+		Quit(),
+	}
+}
+
+func (m CalendarKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{
+			CalendarMove(),
+			CalendarMonth(),
+			CalendarToday(),
+			NextOfDay(),
+			OpenEvent(),
+			CloseCalendar(),
+			Quit(),
+		},
+	}
+}
@@ -1,6 +1,7 @@
 package messages
 
 import (
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
 	"github.com/johannessarpola/lutakkols/pkg/api/options"
 	"time"
@@ -11,6 +12,11 @@ type EventDescriptionFetched struct {
 	ProviderOptions []options.ProviderOption
 }
 
+// DetailsFetched has the details of several events, the events whose details could not be fetched are missing
+type DetailsFetched struct {
+	Details []models.EventDetails
+}
+
 type EventAsciiFetched struct {
 	Ascii string
 }
@@ -18,6 +24,18 @@ type EventAsciiFetched struct {
 type EventsFetched struct {
 	Events []models.Event
 	Time   time.Time
+	Origin models.Origin
 }
 
 type FetchesDone struct{}
+
+type CalendarExported struct {
+	Path string
+}
+
+// Failed is a command which failed, Action tells what was being done and Retry runs the same command again
+type Failed struct {
+	Action string
+	Err    error
+	Retry  tea.Cmd
+}
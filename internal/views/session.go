@@ -0,0 +1,66 @@
+package views
+
+import (
+	"github.com/johannessarpola/lutakkols/internal/state"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"time"
+)
+
+// session is the state kept between the views while the program runs, state is persisted between the runs
+type session struct {
+	details        map[string]models.EventDetails
+	state          *state.State
+	favouritesOnly bool
+	calendarDay    *time.Time // the day selected in the calendar when an event was opened from it
+	exportDir      string     // the directory the events are exported into
+}
+
+func newSession(st *state.State) *session {
+	return &session{
+		details: map[string]models.EventDetails{},
+		state:   st,
+	}
+}
+
+// loadedDetails returns the details of the event if they have been loaded during the session
+func (s *session) loadedDetails(eventID string) *models.EventDetails {
+	if d, ok := s.details[eventID]; ok {
+		return &d
+	}
+	return nil
+}
+
+// toggleFavourite toggles and saves the favourite, returns whether the event is a favourite after it
+func (s *session) toggleFavourite(e models.Event) bool {
+	fav := s.state.ToggleFavourite(e)
+	s.saveState()
+	return fav
+}
+
+// saveState saves the state, the UI keeps working with the state in memory if it fails
+func (s *session) saveState() {
+	if err := s.state.Save(); err != nil {
+		logger.Log.Errorf("Err saving state: %s", err.Error())
+	}
+}
+
+func (s *session) sortMode() sortMode {
+	return parseSortMode(s.state.List.Sort)
+}
+
+func (s *session) groupMode() groupMode {
+	return parseGroupMode(s.state.List.Group)
+}
+
+// cycleSort changes to the next sort mode which is remembered for the next run
+func (s *session) cycleSort() {
+	s.state.List.Sort = string(next(sortModes, s.sortMode()))
+	s.saveState()
+}
+
+// cycleGroup changes to the next group mode which is remembered for the next run
+func (s *session) cycleGroup() {
+	s.state.List.Group = string(next(groupModes, s.groupMode()))
+	s.saveState()
+}
@@ -0,0 +1,191 @@
+package views
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/filter"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sortMode is the order of the events in the list
+type sortMode string
+
+const (
+	sortByDate       sortMode = "date"
+	sortByHeadline   sortMode = "headline"
+	sortByPrice      sortMode = "price"
+	sortInStockFirst sortMode = "in_stock"
+)
+
+var sortModes = []sortMode{sortByDate, sortByHeadline, sortByPrice, sortInStockFirst}
+
+// groupMode is how the events are split into sections in the list
+type groupMode string
+
+const (
+	groupNone    groupMode = "none"
+	groupByMonth groupMode = "month"
+	groupByWeek  groupMode = "week"
+)
+
+var groupModes = []groupMode{groupNone, groupByMonth, groupByWeek}
+
+// next returns the mode after the current one, unknown modes start over from the first one
+func next[T comparable](modes []T, current T) T {
+	i := slices.Index(modes, current)
+	return modes[(i+1)%len(modes)]
+}
+
+func parseSortMode(value string) sortMode {
+	if m := sortMode(value); slices.Contains(sortModes, m) {
+		return m
+	}
+	return sortByDate
+}
+
+func parseGroupMode(value string) groupMode {
+	if m := groupMode(value); slices.Contains(groupModes, m) {
+		return m
+	}
+	return groupNone
+}
+
+func (m sortMode) String() string {
+	if m == sortInStockFirst {
+		return "in stock first"
+	}
+	return "by " + string(m)
+}
+
+// sectionHeader is an item separating the groups in the list, it cannot be selected or filtered
+type sectionHeader struct {
+	title string
+}
+
+func (h sectionHeader) FilterValue() string { return "" }
+
+// sortable is an event with the values it is sorted and grouped by
+type sortable struct {
+	event models.Event
+	day   *time.Time
+	price *models.Price
+}
+
+func newSortable(e models.Event, s *session) sortable {
+	se := sortable{event: e}
+	if d, ok := filter.StartDay(e); ok {
+		se.day = &d
+	}
+	if ed := s.loadedDetails(e.ID()); ed != nil {
+		se.price = ed.LowestPrice()
+	}
+	return se
+}
+
+// compareMissingLast compares the values which may be missing, missing values are after the others
+func compareMissingLast[T any](a *T, b *T, compare func(a T, b T) int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	default:
+		return compare(*a, *b)
+	}
+}
+
+func byDay(a sortable, b sortable) int {
+	if c := compareMissingLast(a.day, b.day, time.Time.Compare); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.event.Order, b.event.Order)
+}
+
+// comparator returns the comparison of the mode, ties are ordered by date
+func (m sortMode) comparator() func(a sortable, b sortable) int {
+	switch m {
+	case sortByHeadline:
+		return func(a sortable, b sortable) int {
+			if c := strings.Compare(strings.ToLower(a.event.Headline), strings.ToLower(b.event.Headline)); c != 0 {
+				return c
+			}
+			return byDay(a, b)
+		}
+	case sortByPrice:
+		return func(a sortable, b sortable) int {
+			if c := compareMissingLast(a.price, b.price, func(a models.Price, b models.Price) int {
+				return cmp.Compare(a.Cents, b.Cents)
+			}); c != 0 {
+				return c
+			}
+			return byDay(a, b)
+		}
+	case sortInStockFirst:
+		return func(a sortable, b sortable) int {
+			if a.event.InStock != b.event.InStock {
+				if a.event.InStock {
+					return -1
+				}
+				return 1
+			}
+			return byDay(a, b)
+		}
+	default:
+		return byDay
+	}
+}
+
+// group returns the start of the group of the day and its title
+func (m groupMode) group(day *time.Time) (*time.Time, string) {
+	if day == nil {
+		return nil, "No date"
+	}
+	switch m {
+	case groupByMonth:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return &start, start.Format("January 2006")
+	case groupByWeek:
+		start := weekStart(*day)
+		year, week := start.ISOWeek()
+		end := start.AddDate(0, 0, 6)
+		return &start, fmt.Sprintf("Week %d/%d · %s–%s", week, year, start.Format("2.1."), end.Format("2.1.2006"))
+	default:
+		return nil, ""
+	}
+}
+
+// sortItems sorts the events and adds a section header before each group when grouping
+func sortItems(events []models.Event, s *session, sm sortMode, gm groupMode) []list.Item {
+	sorted := make([]sortable, len(events))
+	for i, e := range events {
+		sorted[i] = newSortable(e, s)
+	}
+	compare := sm.comparator()
+	slices.SortStableFunc(sorted, func(a sortable, b sortable) int {
+		ga, _ := gm.group(a.day)
+		gb, _ := gm.group(b.day)
+		if c := compareMissingLast(ga, gb, time.Time.Compare); c != 0 {
+			return c
+		}
+		return compare(a, b)
+	})
+
+	items := make([]list.Item, 0, len(sorted))
+	current := ""
+	for i, se := range sorted {
+		if gm != groupNone {
+			if _, title := gm.group(se.day); i == 0 || title != current {
+				items = append(items, sectionHeader{title: title})
+				current = title
+			}
+		}
+		items = append(items, newListItem(se.event, s, false))
+	}
+	return items
+}
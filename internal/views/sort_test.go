@@ -0,0 +1,77 @@
+package views
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/johannessarpola/lutakkols/internal/state"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"slices"
+	"testing"
+)
+
+func sortTestEvents() []models.Event {
+	return []models.Event{
+		{Id: "e1", Order: 1, Headline: "Music Concert", Date: "30.5.2024", InStock: false},
+		{Id: "e2", Order: 2, Headline: "art exhibition", Date: "1.6.2024", InStock: true},
+		{Id: "e3", Order: 3, Headline: "Älymystö", Date: "2.6.2024", InStock: false},
+		{Id: "e4", Order: 4, Headline: "Undated", Date: "TBA", InStock: true},
+	}
+}
+
+// itemNames returns the ids of the events and the titles of the headers
+func itemNames(items []list.Item) []string {
+	var rs []string
+	for _, item := range items {
+		switch i := item.(type) {
+		case sectionHeader:
+			rs = append(rs, "# "+i.title)
+		case EventViewListItem:
+			rs = append(rs, i.Event.ID())
+		}
+	}
+	return rs
+}
+
+func TestSortItems(t *testing.T) {
+	s := newSession(state.New(""))
+	s.details["e3"] = models.EventDetails{EventID: "e3", ParsedDoorPrice: &models.Price{Cents: 1500}}
+	s.details["e4"] = models.EventDetails{EventID: "e4", Tickets: models.EventTickets{Tickets: []models.Ticket{
+		{ParsedPrice: &models.Price{Cents: 2000}}, {ParsedPrice: &models.Price{Cents: 1000}},
+	}}}
+
+	tests := []struct {
+		sort  sortMode
+		group groupMode
+		want  []string
+	}{
+		{sortByDate, groupNone, []string{"e1", "e2", "e3", "e4"}},
+		{sortByHeadline, groupNone, []string{"e2", "e1", "e4", "e3"}},
+		{sortByPrice, groupNone, []string{"e4", "e3", "e1", "e2"}},
+		{sortInStockFirst, groupNone, []string{"e2", "e4", "e1", "e3"}},
+		{sortByDate, groupByMonth, []string{"# May 2024", "e1", "# June 2024", "e2", "e3", "# No date", "e4"}},
+		{sortByHeadline, groupByWeek, []string{"# Week 22/2024 · 27.5.–2.6.2024", "e2", "e1", "e3", "# No date", "e4"}},
+		{sortInStockFirst, groupByMonth, []string{"# May 2024", "e1", "# June 2024", "e2", "e3", "# No date", "e4"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.sort)+"/"+string(tt.group), func(t *testing.T) {
+			got := itemNames(sortItems(sortTestEvents(), s, tt.sort, tt.group))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestModes(t *testing.T) {
+	if m := next(sortModes, sortInStockFirst); m != sortByDate {
+		t.Errorf("expected the sort modes to start over, got %s", m)
+	}
+	if m := next(groupModes, groupNone); m != groupByMonth {
+		t.Errorf("expected month after none, got %s", m)
+	}
+	if m := parseSortMode("unknown"); m != sortByDate {
+		t.Errorf("expected unknown sort mode to default to date, got %s", m)
+	}
+	if m := parseGroupMode(""); m != groupNone {
+		t.Errorf("expected no grouping by default, got %s", m)
+	}
+}
@@ -1,7 +1,9 @@
 package views
 
 import (
+	"fmt"
 	"github.com/johannessarpola/lutakkols/internal/views/spinner"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
 )
 
 func newSpinner() spinner.Model {
@@ -9,3 +11,11 @@ func newSpinner() spinner.Model {
 	n.Spinner = spinner.LutakkoSpinner
 	return n
 }
+
+// withOrigin appends the source of the data if it is known
+func withOrigin(s string, origin models.Origin) string {
+	if len(origin) == 0 {
+		return s
+	}
+	return fmt.Sprintf("%s (%s)", s, origin)
+}
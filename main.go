@@ -2,12 +2,14 @@ package main
 
 import (
 	"github.com/johannessarpola/lutakkols/cmd"
+	"github.com/johannessarpola/lutakkols/cmd/exitcode"
+	"os"
 )
 
 func main() {
 	err := cmd.Execute()
 	if err != nil {
-		// todo
-		return
+		// cobra has already printed the error
+		os.Exit(exitcode.Code(err))
 	}
 }
@@ -10,9 +10,12 @@ func main() {
 		SourceURL:      "https://www.jelmu.net",
 		EventsFn:       ".data/events_test.json",
 		EventDetailsFn: ".data/event_details_test.json",
+		EventAsciiFn:   ".data/event_ascii_test.json",
 		Timeout:        30 * time.Second,
 		RateLimit:      1 * time.Second,
-		EventLimit:     10,
+		Concurrency:    4,
+		TaskTimeout:    20 * time.Second,
+		DetailsLimit:   10,
 		Verbose:        true,
 	}
 	sync.Run(c)
@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/internal/hybrid"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"time"
+)
+
+type HybridBuilder struct {
+	online  OnlineBuilder
+	offline OfflineBuilder
+	Timeout time.Duration
+}
+
+func (b *HybridBuilder) WithDefaultOpts(opts ...options.ProviderOption) *HybridBuilder {
+	b.online.WithDefaultOpts(opts...)
+	b.offline.WithDefaultOpts(opts...)
+	return b
+}
+
+func (b *HybridBuilder) WitEventsSourceURL(path string) *HybridBuilder {
+	b.online.WitEventsSourceURL(path)
+	return b
+}
+
+func (b *HybridBuilder) WithSources(specs ...string) *HybridBuilder {
+	b.online.WithSources(specs...)
+	return b
+}
+
+func (b *HybridBuilder) WithEventSourceFsPath(path string) *HybridBuilder {
+	b.offline.WithEventSourceFsPath(path)
+	return b
+}
+
+func (b *HybridBuilder) WithEventDetailsFsPath(path string) *HybridBuilder {
+	b.offline.WithEventDetailsFsPath(path)
+	return b
+}
+
+func (b *HybridBuilder) WithEventAsciiFsPath(path string) *HybridBuilder {
+	b.offline.WithEventAsciiFsPath(path)
+	return b
+}
+
+func (b *HybridBuilder) WithAsciiGen(genFunc func(string, string) string) *HybridBuilder {
+	b.offline.WithAsciiGen(genFunc)
+	return b
+}
+
+func (b *HybridBuilder) WithTimeout(timeout time.Duration) *HybridBuilder {
+	b.Timeout = timeout
+	return b
+}
+
+func (b *HybridBuilder) Build() (*hybrid.Provider, error) {
+	if !b.online.validateParameters() || !b.offline.validateParameters() {
+		return nil, errors.New("invalid parameters")
+	}
+
+	on, err := b.online.Build()
+	if err != nil {
+		return nil, err
+	}
+	off, err := b.offline.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return hybrid.New(on, off, b.offline.EventSourceFsPath, b.offline.EventDetailsFsPath, b.offline.EventAsciiFsPath, b.Timeout), nil
+}
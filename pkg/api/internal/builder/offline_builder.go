@@ -11,6 +11,7 @@ type OfflineBuilder struct {
 	DefaultOpts        []options.ProviderOption
 	EventSourceFsPath  string
 	EventDetailsFsPath string
+	EventAsciiFsPath   string
 	AsciiGen           func(string, string) string
 }
 
@@ -29,6 +30,12 @@ func (b *OfflineBuilder) WithEventDetailsFsPath(path string) *OfflineBuilder {
 	return b
 }
 
+// WithEventAsciiFsPath sets the optional ascii art file written by sync
+func (b *OfflineBuilder) WithEventAsciiFsPath(path string) *OfflineBuilder {
+	b.EventAsciiFsPath = path
+	return b
+}
+
 func (b *OfflineBuilder) WithAsciiGen(genFunc func(string, string) string) *OfflineBuilder {
 	b.AsciiGen = genFunc
 	return b
@@ -61,6 +68,7 @@ func (b *OfflineBuilder) Build() (*offline.Provider, error) {
 
 	p := offline.New(b.EventSourceFsPath,
 		b.EventDetailsFsPath,
+		b.EventAsciiFsPath,
 		b.AsciiGen,
 		b.DefaultOpts...)
 	return &p, nil
@@ -5,10 +5,12 @@ import (
 	"github.com/johannessarpola/lutakkols/pkg/api/internal/online"
 	"github.com/johannessarpola/lutakkols/pkg/api/options"
 	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/sources"
 )
 
 type OnlineBuilder struct {
 	EventsSourceURL string
+	Sources         []string
 	DefaultOpts     []options.ProviderOption
 }
 
@@ -22,8 +24,14 @@ func (b *OnlineBuilder) WitEventsSourceURL(path string) *OnlineBuilder {
 	return b
 }
 
+// WithSources sets the source specs (name or name=url), without them the jelmu source is used from EventsSourceURL
+func (b *OnlineBuilder) WithSources(specs ...string) *OnlineBuilder {
+	b.Sources = specs
+	return b
+}
+
 func (b *OnlineBuilder) validateParameters() bool {
-	if len(b.EventsSourceURL) == 0 {
+	if len(b.EventsSourceURL) == 0 && len(b.Sources) == 0 {
 		logger.Log.Error("Misconfiguration: Event source URL is empty")
 		return false
 	}
@@ -31,11 +39,23 @@ func (b *OnlineBuilder) validateParameters() bool {
 	return true
 }
 
+func (b *OnlineBuilder) sourceSpecs() []string {
+	if len(b.Sources) > 0 {
+		return b.Sources
+	}
+	return []string{sources.Jelmu + "=" + b.EventsSourceURL}
+}
+
 func (b *OnlineBuilder) Build() (*online.Provider, error) {
 	if !b.validateParameters() {
 		return nil, errors.New("invalid parameters")
 	}
 
-	p := online.New(b.EventsSourceURL, b.DefaultOpts...)
+	src, err := sources.FromSpecs(b.sourceSpecs()...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := online.New(src, b.DefaultOpts...)
 	return &p, nil
 }
@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/internal/storage"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/store"
+)
+
+type StoreBuilder struct {
+	DefaultOpts []options.ProviderOption
+	StorePath   string
+	AsciiGen    func(string, string) string
+}
+
+func (b *StoreBuilder) WithDefaultOpts(opts ...options.ProviderOption) *StoreBuilder {
+	b.DefaultOpts = opts
+	return b
+}
+
+func (b *StoreBuilder) WithStorePath(path string) *StoreBuilder {
+	b.StorePath = path
+	return b
+}
+
+func (b *StoreBuilder) WithAsciiGen(genFunc func(string, string) string) *StoreBuilder {
+	b.AsciiGen = genFunc
+	return b
+}
+
+func (b *StoreBuilder) validateParameters() bool {
+	if len(b.StorePath) == 0 {
+		logger.Log.Error("Misconfiguration: store path is empty")
+		return false
+	}
+
+	if b.AsciiGen == nil {
+		logger.Log.Error("Misconfiguration: AsciiGen function is nil")
+		return false
+	}
+
+	return true
+}
+
+func (b *StoreBuilder) Build() (*storage.Provider, error) {
+	if !b.validateParameters() {
+		return nil, errors.New("invalid parameters")
+	}
+
+	s, err := store.Open(b.StorePath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := storage.New(s, b.AsciiGen, b.DefaultOpts...)
+	return &p, nil
+}
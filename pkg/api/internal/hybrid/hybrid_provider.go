@@ -0,0 +1,262 @@
+// Package hybrid contains the provider which serves online data and falls back to the offline data when the
+// online fetches fail, successful online fetches are written to disk so that the offline data stays fresh
+package hybrid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/internal/loadfs"
+	"github.com/johannessarpola/lutakkols/pkg/api/internal/offline"
+	"github.com/johannessarpola/lutakkols/pkg/api/internal/online"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/snapshot"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is used for online fetches when no timeout is configured
+const DefaultTimeout = 10 * time.Second
+
+type Provider struct {
+	online           *online.Provider
+	offline          *offline.Provider
+	eventsPath       string
+	eventDetailsPath string
+	eventAsciiPath   string
+	timeout          time.Duration
+	writeMtx         sync.Mutex
+	// seen has the digests of the online answers already handled so that the repeated ones, such as the cache hits
+	// of the online provider, do not read the files again
+	seen map[string]digest
+}
+
+type digest [sha256.Size]byte
+
+func digestOf(v any) digest {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return digest{}
+	}
+	return sha256.Sum256(b)
+}
+
+// handled tells if the answer under the key has been handled already and remembers it otherwise, must be called
+// with the writeMtx held
+func (m *Provider) handled(key string, v any) bool {
+	d := digestOf(v)
+	if m.seen[key] == d {
+		return true
+	}
+	m.seen[key] = d
+	return false
+}
+
+// New instantiates the hybrid provider over the online and offline providers, the paths should be the same the
+// offline provider reads from, ascii art is not written when eventAsciiPath is empty
+func New(
+	onlineProvider *online.Provider,
+	offlineProvider *offline.Provider,
+	eventsPath string,
+	eventDetailsPath string,
+	eventAsciiPath string,
+	timeout time.Duration,
+) *Provider {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Provider{
+		online:           onlineProvider,
+		offline:          offlineProvider,
+		eventsPath:       eventsPath,
+		eventDetailsPath: eventDetailsPath,
+		eventAsciiPath:   eventAsciiPath,
+		timeout:          timeout,
+		seen:             map[string]digest{},
+	}
+}
+
+// fallback checks if the offline data should be used, it should not when the caller is not waiting anymore
+func fallback(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() == nil
+}
+
+// offlineOpts makes the offline provider read the files as they might have been written after caching
+func offlineOpts(opts []options.ProviderOption) []options.ProviderOption {
+	return append(opts, options.SkipCache)
+}
+
+func (m *Provider) GetAscii(ctx context.Context, eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error) {
+	tctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	ea, err := m.online.GetAscii(tctx, eventID, imageURL, opts...)
+	if err == nil {
+		m.writeAscii(ea)
+		return ea, nil
+	}
+	if !fallback(ctx, err) {
+		return ea, err
+	}
+
+	logger.Log.Warnf("falling back to offline ascii for %s: %v", eventID, err)
+	return m.offline.GetAscii(ctx, eventID, imageURL, offlineOpts(opts)...)
+}
+
+func (m *Provider) GetDetails(ctx context.Context, eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error) {
+	tctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	ed, err := m.online.GetDetails(tctx, eventID, eventURL, opts...)
+	if err == nil {
+		m.writeDetails(ed)
+		return ed, nil
+	}
+	if !fallback(ctx, err) {
+		return ed, err
+	}
+
+	logger.Log.Warnf("falling back to offline details for %s: %v", eventID, err)
+	fed, ferr := m.offline.GetDetails(ctx, eventID, eventURL, offlineOpts(opts)...)
+	if ferr != nil {
+		return fed, errors.Join(err, ferr)
+	}
+	return fed, nil
+}
+
+func (m *Provider) GetEvents(ctx context.Context, opts ...options.ProviderOption) (*models.Events, error) {
+	tctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	events, err := m.online.GetEvents(tctx, opts...)
+	if err == nil {
+		m.writeEvents(events)
+		return events, nil
+	}
+	if !fallback(ctx, err) {
+		return nil, err
+	}
+
+	logger.Log.Warnf("falling back to offline events: %v", err)
+	fe, ferr := m.offline.GetEvents(ctx, offlineOpts(opts)...)
+	if ferr != nil {
+		return nil, errors.Join(err, ferr)
+	}
+	return fe, nil
+}
+
+// writeEvents replaces the offline listing with the online one, events missing from it are kept as archived. The
+// file is only written when the merged listing differs from it.
+func (m *Provider) writeEvents(events *models.Events) {
+	m.writeMtx.Lock()
+	defer m.writeMtx.Unlock()
+	if m.handled("events", events.Events) {
+		return
+	}
+
+	var prev snapshot.Snapshot
+	existing, err := loadfs.Events(m.eventsPath)
+	if err != nil && !os.IsNotExist(err) {
+		logger.Log.Warnf("could not read events from %s: %v", m.eventsPath, err)
+		delete(m.seen, "events")
+		return
+	}
+	if existing != nil {
+		prev.Events = existing.Events
+	}
+	merged, _ := prev.Merge(events.Events, nil, nil)
+	if existing != nil && digestOf(merged.Events) == digestOf(prev.Events) {
+		return
+	}
+
+	err = merged.Write(snapshot.Paths{Events: m.eventsPath})
+	if err != nil {
+		logger.Log.Warnf("could not write events to %s: %v", m.eventsPath, err)
+		delete(m.seen, "events")
+	}
+}
+
+// writeDetails updates or appends the details into the offline details when they differ from the ones there
+func (m *Provider) writeDetails(ed models.EventDetails) {
+	m.writeMtx.Lock()
+	defer m.writeMtx.Unlock()
+	key := "details/" + ed.ID()
+	if m.handled(key, ed) {
+		return
+	}
+
+	all, err := loadfs.AllDetails(m.eventDetailsPath)
+	if err != nil && !os.IsNotExist(err) {
+		logger.Log.Warnf("could not read details from %s: %v", m.eventDetailsPath, err)
+		delete(m.seen, key)
+		return
+	}
+
+	all, changed := upsert(all, ed, func(prev models.EventDetails) bool {
+		// the details are timestamped when fetched so the time is not compared
+		cur := ed
+		cur.UpdatedAt = prev.UpdatedAt
+		return digestOf(prev) == digestOf(cur)
+	})
+	if !changed {
+		return
+	}
+
+	err = snapshot.Snapshot{Details: all}.Write(snapshot.Paths{Details: m.eventDetailsPath})
+	if err != nil {
+		logger.Log.Warnf("could not write details to %s: %v", m.eventDetailsPath, err)
+		delete(m.seen, key)
+	}
+}
+
+// writeAscii updates or appends the ascii art into the offline ascii when it differs from the one there
+func (m *Provider) writeAscii(ea models.EventAscii) {
+	if len(m.eventAsciiPath) == 0 {
+		return
+	}
+	m.writeMtx.Lock()
+	defer m.writeMtx.Unlock()
+	key := "ascii/" + ea.ID()
+	if m.handled(key, ea) {
+		return
+	}
+
+	all, err := loadfs.AllAscii(m.eventAsciiPath)
+	if err != nil && !os.IsNotExist(err) {
+		logger.Log.Warnf("could not read ascii from %s: %v", m.eventAsciiPath, err)
+		delete(m.seen, key)
+		return
+	}
+
+	all, changed := upsert(all, ea, func(prev models.EventAscii) bool {
+		return prev.Ascii == ea.Ascii
+	})
+	if !changed {
+		return
+	}
+
+	err = snapshot.Snapshot{Ascii: all}.Write(snapshot.Paths{Ascii: m.eventAsciiPath})
+	if err != nil {
+		logger.Log.Warnf("could not write ascii to %s: %v", m.eventAsciiPath, err)
+		delete(m.seen, key)
+	}
+}
+
+// upsert replaces the element with the same id or appends it, nothing is replaced when same tells the previous
+// element is the same
+func upsert[T models.HasID](all []T, v T, same func(prev T) bool) ([]T, bool) {
+	for i, existing := range all {
+		if existing.ID() == v.ID() {
+			if same(existing) {
+				return all, false
+			}
+			all[i] = v
+			return all, true
+		}
+	}
+	return append(all, v), true
+}
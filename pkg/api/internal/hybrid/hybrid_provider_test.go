@@ -0,0 +1,181 @@
+package hybrid
+
+import (
+	"context"
+	"github.com/johannessarpola/lutakkols/pkg/api/internal/loadfs"
+	"github.com/johannessarpola/lutakkols/pkg/api/internal/offline"
+	"github.com/johannessarpola/lutakkols/pkg/api/internal/online"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"github.com/johannessarpola/lutakkols/pkg/sources"
+	"github.com/johannessarpola/lutakkols/pkg/writer"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const listing = `<html><body><ul class="products">
+<li><a href="/tapahtuma/band-one/">x</a><a href="/kauppa/band-one/">y</a><h2>Band One</h2></li>
+</ul></body></html>`
+
+func newTestProvider(t *testing.T, handler http.HandlerFunc) (*Provider, string) {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	ep := filepath.Join(dir, "events.json")
+	edp := filepath.Join(dir, "event_details.json")
+	eap := filepath.Join(dir, "event_ascii.json")
+	_ = writer.WriteJson([]models.Event{{Id: "offline-event"}}, ep)
+	_ = writer.WriteJson([]models.EventDetails{{EventID: "offline-event"}}, edp)
+
+	src, err := sources.New(sources.Jelmu, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := online.New(src, options.SkipCache)
+	off := offline.New(ep, edp, eap, func(_ string, _ string) string { return "placeholder" })
+	return New(&on, &off, ep, edp, eap, time.Second), ep
+}
+
+func TestFallback(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	events, err := p.GetEvents(context.Background())
+	if err != nil {
+		t.Fatalf("expected fallback, got %s", err)
+	}
+	if events.Origin != models.OriginOffline || events.Events[0].Id != "offline-event" {
+		t.Errorf("events were not served from offline: %+v", events)
+	}
+
+	ea, err := p.GetAscii(context.Background(), "offline-event", "http://127.0.0.1:0/missing.png")
+	if err != nil || ea.Origin != models.OriginOffline || ea.Ascii != "placeholder" {
+		t.Errorf("ascii was not served from offline: %v", err)
+	}
+
+	ed, err := p.GetDetails(context.Background(), "offline-event", "http://127.0.0.1:0/missing")
+	if err != nil || ed.Origin != models.OriginOffline {
+		t.Errorf("details were not served from offline: %v", err)
+	}
+
+	_, err = p.GetDetails(context.Background(), "unknown", "http://127.0.0.1:0/missing")
+	if err == nil {
+		t.Errorf("expected error when both sources fail")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	})
+	p.timeout = 50 * time.Millisecond
+
+	events, err := p.GetEvents(context.Background())
+	if err != nil || events.Origin != models.OriginOffline {
+		t.Errorf("expected fallback on timeout, got %v", err)
+	}
+}
+
+func TestWriteBack(t *testing.T) {
+	p, ep := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(listing))
+	})
+
+	events, err := p.GetEvents(context.Background())
+	if err != nil {
+		t.Fatalf("err getting events: %s", err)
+	}
+	if events.Origin != models.OriginOnline {
+		t.Errorf("events were not served from online")
+	}
+
+	written, err := loadfs.Events(ep)
+	if err != nil || len(written.Events) != 2 || written.Events[0].Id != "band-one" {
+		t.Fatalf("online events were not written back: %+v", written)
+	}
+	if !written.Events[1].Archived || written.Events[1].Id != "offline-event" {
+		t.Errorf("event missing from the online listing was not archived: %+v", written.Events[1])
+	}
+}
+
+func TestWriteBackUnchanged(t *testing.T) {
+	p, ep := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(listing))
+	})
+
+	if _, err := p.GetEvents(context.Background()); err != nil {
+		t.Fatalf("err getting events: %s", err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(ep, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same listing fetched again is not written
+	if _, err := p.GetEvents(context.Background()); err != nil {
+		t.Fatalf("err getting events: %s", err)
+	}
+	if fi, err := os.Stat(ep); err != nil || !fi.ModTime().Equal(old) {
+		t.Errorf("unchanged events were written again")
+	}
+
+	// nor the same answer handled already, even when the file has changed since
+	if err := writer.WriteJson([]models.Event{{Id: "synced-event"}}, ep); err != nil {
+		t.Fatal(err)
+	}
+	p.writeEvents(&models.Events{Events: []models.Event{{Id: "band-one", Headline: "Band One"}}})
+	p.writeEvents(&models.Events{Events: []models.Event{{Id: "band-one", Headline: "Band One"}}})
+	written, err := loadfs.Events(ep)
+	if err != nil || len(written.Events) != 2 || written.Events[1].Id != "synced-event" {
+		t.Fatalf("changed events were not written: %+v", written)
+	}
+	_ = writer.WriteJson([]models.Event{{Id: "synced-event"}}, ep)
+	p.writeEvents(&models.Events{Events: []models.Event{{Id: "band-one", Headline: "Band One"}}})
+	written, err = loadfs.Events(ep)
+	if err != nil || len(written.Events) != 1 {
+		t.Errorf("a repeated answer was written again: %+v", written)
+	}
+}
+
+func TestWriteDetailsUnchanged(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {})
+	ed := models.EventDetails{EventID: "offline-event", UpdatedAt: time.Now()}
+	p.writeDetails(ed)
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(p.eventDetailsPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// fetched again later, only the time differs
+	ed.UpdatedAt = time.Now().Add(time.Minute)
+	p.writeDetails(ed)
+	if fi, err := os.Stat(p.eventDetailsPath); err != nil || !fi.ModTime().Equal(old) {
+		t.Errorf("unchanged details were written again")
+	}
+
+	ed.Description = []string{"changed"}
+	p.writeDetails(ed)
+	all, err := loadfs.AllDetails(p.eventDetailsPath)
+	if err != nil || len(all) != 1 || len(all[0].Description) != 1 {
+		t.Errorf("changed details were not written: %+v", all)
+	}
+}
+
+func TestNoFallbackWhenCancelled(t *testing.T) {
+	p, _ := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := p.GetEvents(ctx)
+	if err == nil {
+		t.Errorf("expected error with cancelled context")
+	}
+}
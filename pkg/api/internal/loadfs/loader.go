@@ -37,8 +37,8 @@ func Events(fp string) (*models.Events, error) {
 	}, nil
 }
 
-// loadAllDetails loads all eent details from a json file
-func loadAllDetails(fp string) ([]models.EventDetails, error) {
+// AllDetails loads all event details from a json file
+func AllDetails(fp string) ([]models.EventDetails, error) {
 	// Open the JSON file
 	file, err := os.Open(fp)
 	if err != nil {
@@ -61,7 +61,7 @@ func loadAllDetails(fp string) ([]models.EventDetails, error) {
 
 // EventDetails loads a single event detail from the details json file
 func EventDetails(eventID string, fp string) (models.EventDetails, error) {
-	eventDetails, err := loadAllDetails(fp)
+	eventDetails, err := AllDetails(fp)
 	var ed models.EventDetails
 	if err != nil {
 		return ed, err
@@ -76,3 +76,39 @@ func EventDetails(eventID string, fp string) (models.EventDetails, error) {
 		Path: fp,
 	}
 }
+
+// AllAscii loads all ascii art from a json file
+func AllAscii(fp string) ([]models.EventAscii, error) {
+	file, err := os.Open(fp)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	var ascii []models.EventAscii
+	err = json.NewDecoder(file).Decode(&ascii)
+	if err != nil {
+		return nil, err
+	}
+	return ascii, nil
+}
+
+// EventAscii loads the ascii art of a single event from the ascii json file
+func EventAscii(eventID string, fp string) (models.EventAscii, error) {
+	all, err := AllAscii(fp)
+	var ea models.EventAscii
+	if err != nil {
+		return ea, err
+	}
+	for _, ea = range all {
+		if eventID == ea.ID() {
+			return ea, nil
+		}
+	}
+	return models.EventAscii{}, notFoundException{
+		ID:   eventID,
+		Path: fp,
+	}
+}
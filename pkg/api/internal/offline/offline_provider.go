@@ -2,6 +2,7 @@
 package offline
 
 import (
+	"context"
 	"github.com/johannessarpola/lutakkols/pkg/api/internal/caching"
 	"github.com/johannessarpola/lutakkols/pkg/api/internal/loadfs"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
@@ -13,6 +14,7 @@ import (
 type Provider struct {
 	eventsPath       string
 	eventDetailsPath string
+	eventAsciiPath   string
 	fetchCache       *caching.EventCache
 	defaultOpts      []options.ProviderOption
 	asciiGen         func(string, string) string
@@ -28,10 +30,12 @@ var ttlOptions = caching.TTLOptions{
 	Capacity:        1000,
 }
 
-// New instantiate the offline providers, the onlineURL will only be used if the data is synchronized on demand
+// New instantiate the offline providers, the eventAsciiPath is optional and asciiGenerator is used for the events
+// without synced ascii art
 func New(
 	eventsPath string,
 	eventDetailsPath string,
+	eventAsciiPath string,
 	asciiGenerator func(string, string) string,
 	opts ...options.ProviderOption,
 ) Provider {
@@ -45,6 +49,7 @@ func New(
 	return Provider{
 		eventsPath:       eventsPath,
 		eventDetailsPath: eventDetailsPath,
+		eventAsciiPath:   eventAsciiPath,
 		defaultOpts:      opts,
 		asciiGen:         asciiGenerator,
 		fetchCache:       c,
@@ -59,15 +64,38 @@ func (m *Provider) withInitialOpts(additionalOpts []options.ProviderOption) []op
 	return append(m.defaultOpts, additionalOpts...)
 }
 
-// GetAscii just prints placeholder string since it is not possible to do this offline (yet)
-func (m *Provider) GetAscii(eventID string, imageURL string, _ ...options.ProviderOption) (models.EventAscii, error) {
+// GetAscii serves the ascii art captured by sync, the placeholder from asciiGen is used when there is none
+func (m *Provider) GetAscii(ctx context.Context, eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error) {
+	if m.useCache(opts) {
+		value, ts, ok := m.fetchCache.GetAscii(eventID)
+		if ok {
+			value.UpdatedAt = ts
+			logger.Log.Debugf("fetched ascii from caching with id %s", eventID)
+			return value, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return models.EventAscii{}, err
+	}
+	if len(m.eventAsciiPath) > 0 {
+		ea, err := loadfs.EventAscii(eventID, m.eventAsciiPath)
+		if err == nil {
+			ea.Origin = models.OriginOffline
+			m.fetchCache.SetAscii(eventID, ea)
+			return ea, nil
+		}
+		logger.Log.Debugf("no ascii for %s, using placeholder: %v", eventID, err)
+	}
+
 	return models.EventAscii{
 		Ascii:   m.asciiGen(eventID, imageURL),
 		EventID: eventID,
+		Origin:  models.OriginOffline,
 	}, nil
 }
 
-func (m *Provider) GetDetails(eventID string, _ string, opts ...options.ProviderOption) (models.EventDetails, error) {
+func (m *Provider) GetDetails(ctx context.Context, eventID string, _ string, opts ...options.ProviderOption) (models.EventDetails, error) {
 	var ed models.EventDetails
 	var err error
 
@@ -80,14 +108,18 @@ func (m *Provider) GetDetails(eventID string, _ string, opts ...options.Provider
 		}
 	}
 
+	if err = ctx.Err(); err != nil {
+		return ed, err
+	}
 	ed, err = loadfs.EventDetails(eventID, m.eventDetailsPath)
+	ed.Origin = models.OriginOffline
 	if err == nil {
 		m.fetchCache.SetDetails(eventID, ed)
 	}
 	return ed, err
 }
 
-func (m *Provider) GetEvents(opts ...options.ProviderOption) (*models.Events, error) {
+func (m *Provider) GetEvents(ctx context.Context, opts ...options.ProviderOption) (*models.Events, error) {
 	if m.useCache(opts) {
 		value, ts, ok := m.fetchCache.GetEvents()
 		if ok {
@@ -96,8 +128,13 @@ func (m *Provider) GetEvents(opts ...options.ProviderOption) (*models.Events, er
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	events, err := loadfs.Events(m.eventsPath)
 	if events != nil {
+		*events = events.Listed()
+		events.Origin = models.OriginOffline
 		m.fetchCache.SetEvents(*events)
 	}
 	return events, err
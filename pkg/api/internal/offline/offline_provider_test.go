@@ -1,6 +1,7 @@
 package offline
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -14,9 +15,9 @@ func TestOfflineProvider(t *testing.T) {
 	ep := "test_data/events_test.json"
 	placeholderGen := func(_ string, _ string) string { return "" }
 
-	ofp := New(ep, edp, placeholderGen)
+	ofp := New(ep, edp, "", placeholderGen)
 
-	events, err := ofp.GetEvents()
+	events, err := ofp.GetEvents(context.Background())
 	if events == nil {
 		t.Errorf("events is nil")
 		return
@@ -28,9 +29,26 @@ func TestOfflineProvider(t *testing.T) {
 	}
 
 	event := events.Events[0]
-	_, err = ofp.GetDetails(event.ID(), event.EventURL())
+	_, err = ofp.GetDetails(context.Background(), event.ID(), event.EventURL())
 	if err != nil {
 		t.Errorf("err getting details for %s", event.Headline)
 	}
 
 }
+
+func TestOfflineAscii(t *testing.T) {
+	ofp := New("test_data/events_test.json",
+		"test_data/event_details_test.json",
+		"test_data/event_ascii_test.json",
+		func(_ string, _ string) string { return "placeholder" })
+
+	ea, err := ofp.GetAscii(context.Background(), "event1", "")
+	if err != nil || ea.Ascii != "#####" {
+		t.Errorf("expected synced ascii, got %s with err %v", ea.Ascii, err)
+	}
+
+	ea, err = ofp.GetAscii(context.Background(), "event2", "")
+	if err != nil || ea.Ascii != "placeholder" {
+		t.Errorf("expected placeholder for missing ascii, got %s with err %v", ea.Ascii, err)
+	}
+}
@@ -2,17 +2,19 @@
 package online
 
 import (
+	"context"
 	"errors"
 	"github.com/johannessarpola/lutakkols/pkg/api/internal/caching"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
 	"github.com/johannessarpola/lutakkols/pkg/api/options"
-	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/httpcache"
 	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/sources"
 	"time"
 )
 
 type Provider struct {
-	sourceURL   string
+	source      sources.Source
 	fetchCache  *caching.EventCache
 	defaultOpts []options.ProviderOption
 }
@@ -29,7 +31,16 @@ func (m *Provider) useCache(opts []options.ProviderOption) bool {
 	return !options.Has(options.SkipCache, m.withInitialOpts(opts)) && m.fetchCache != nil
 }
 
-func New(eventsSourceURL string, opts ...options.ProviderOption) Provider {
+// fetchContext makes the fetches revalidate the HTTP cache as well when the cache should be skipped
+func (m *Provider) fetchContext(ctx context.Context, opts []options.ProviderOption) context.Context {
+	if options.Has(options.SkipCache, m.withInitialOpts(opts)) {
+		return httpcache.WithRevalidate(ctx)
+	}
+	return ctx
+}
+
+// New instantiates the provider over the source, use sources.Multi to serve several sources
+func New(source sources.Source, opts ...options.ProviderOption) Provider {
 
 	c, err := caching.New(ttlOptions)
 	if err != nil {
@@ -38,7 +49,7 @@ func New(eventsSourceURL string, opts ...options.ProviderOption) Provider {
 	}
 
 	return Provider{
-		sourceURL:   eventsSourceURL,
+		source:      source,
 		fetchCache:  c,
 		defaultOpts: opts,
 	}
@@ -48,7 +59,7 @@ func (m *Provider) withInitialOpts(additionalOpts []options.ProviderOption) []op
 	return append(m.defaultOpts, additionalOpts...)
 }
 
-func (m *Provider) GetAscii(eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error) {
+func (m *Provider) GetAscii(ctx context.Context, eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error) {
 	var ea models.EventAscii
 	var err error
 
@@ -64,14 +75,15 @@ func (m *Provider) GetAscii(eventID string, imageURL string, opts ...options.Pro
 		}
 	}
 
-	ea, err = fetch.Sync.EventImage(imageURL, eventID)
+	ea, err = m.source.EventImage(m.fetchContext(ctx, opts), imageURL, eventID)
+	ea.Origin = models.OriginOnline
 	if err == nil {
 		m.fetchCache.SetAscii(eventID, ea)
 	}
 	return ea, err
 }
 
-func (m *Provider) GetDetails(eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error) {
+func (m *Provider) GetDetails(ctx context.Context, eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error) {
 	var ed models.EventDetails
 	var err error
 
@@ -87,7 +99,8 @@ func (m *Provider) GetDetails(eventID string, eventURL string, opts ...options.P
 		}
 	}
 
-	ed, err = fetch.Sync.EventDetails(eventURL, eventID)
+	ed, err = m.source.EventDetails(m.fetchContext(ctx, opts), eventURL, eventID)
+	ed.Origin = models.OriginOnline
 	if err == nil {
 		m.fetchCache.SetDetails(eventID, ed)
 	}
@@ -95,7 +108,7 @@ func (m *Provider) GetDetails(eventID string, eventURL string, opts ...options.P
 	return ed, err
 }
 
-func (m *Provider) GetEvents(opts ...options.ProviderOption) (*models.Events, error) {
+func (m *Provider) GetEvents(ctx context.Context, opts ...options.ProviderOption) (*models.Events, error) {
 	if m.useCache(opts) {
 		value, ts, ok := m.fetchCache.GetEvents()
 		if ok {
@@ -104,7 +117,7 @@ func (m *Provider) GetEvents(opts ...options.ProviderOption) (*models.Events, er
 		}
 	}
 
-	list, err := fetch.Sync.Events(m.sourceURL)
+	list, err := m.source.Events(m.fetchContext(ctx, opts))
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +129,7 @@ func (m *Provider) GetEvents(opts ...options.ProviderOption) (*models.Events, er
 	events := models.Events{
 		Events:    list,
 		UpdatedAt: time.Now(),
+		Origin:    models.OriginOnline,
 	}
 
 	if list != nil {
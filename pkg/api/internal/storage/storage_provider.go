@@ -0,0 +1,61 @@
+// Package storage contains the provider which serves the data synchronized into the SQLite store
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/store"
+)
+
+type Provider struct {
+	store       *store.Store
+	defaultOpts []options.ProviderOption
+	asciiGen    func(string, string) string
+}
+
+// New instantiates the store provider, asciiGenerator is used for events which do not have ascii art in the store
+func New(s *store.Store, asciiGenerator func(string, string) string, opts ...options.ProviderOption) Provider {
+	return Provider{
+		store:       s,
+		defaultOpts: opts,
+		asciiGen:    asciiGenerator,
+	}
+}
+
+// GetAscii returns the stored ascii art or the placeholder if there is none
+func (m *Provider) GetAscii(ctx context.Context, eventID string, imageURL string, _ ...options.ProviderOption) (models.EventAscii, error) {
+	ea, err := m.store.Ascii(ctx, eventID)
+	var nf store.NotFoundError
+	if errors.As(err, &nf) {
+		logger.Log.Debugf("no ascii in store for %s, using placeholder", eventID)
+		return models.EventAscii{
+			Ascii:   m.asciiGen(eventID, imageURL),
+			EventID: eventID,
+			Origin:  models.OriginStore,
+		}, nil
+	}
+	ea.Origin = models.OriginStore
+	return ea, err
+}
+
+func (m *Provider) GetDetails(ctx context.Context, eventID string, _ string, _ ...options.ProviderOption) (models.EventDetails, error) {
+	ed, err := m.store.Details(ctx, eventID)
+	ed.Origin = models.OriginStore
+	return ed, err
+}
+
+func (m *Provider) GetEvents(ctx context.Context, _ ...options.ProviderOption) (*models.Events, error) {
+	events, err := m.store.Events(ctx)
+	if err != nil {
+		return nil, err
+	}
+	*events = events.Listed()
+	if len(events.Events) == 0 {
+		return nil, errors.New("no events found in store")
+	}
+	events.Origin = models.OriginStore
+	return events, nil
+}
@@ -3,37 +3,64 @@ package models
 
 import "time"
 
-// Event is an event with some basic information scraped from the shorter description
+// Origin tells where the provider served the data from, it is not persisted
+type Origin string
+
+const (
+	OriginOnline  Origin = "online"
+	OriginOffline Origin = "offline"
+	OriginStore   Origin = "store"
+)
+
+// Event is an event with some basic information scraped from the shorter description,
+// StartsAt is the parsed Date and nil when it could not be parsed, Source is the name of the source which listed the
+// event and Venue where it takes place. Archived events are not listed by the source anymore but are kept by sync.
 type Event struct {
-	Id             string    `json:"id"`
-	Order          int       `json:"order"`
-	Headline       string    `json:"headline"`
-	EventLink      string    `json:"event_link"`
-	SmallImageLink string    `json:"image_link"`
-	Weekday        string    `json:"week_day"`
-	Date           string    `json:"date"`
-	StoreLink      string    `json:"store_ink"`
-	InStock        bool      `json:"in_stock"`
-	BulletPoints   []string  `json:"bullet_points"`
-	UpdatedAt      time.Time `json:"updated_at,omitempty"`
+	Id             string     `json:"id"`
+	Order          int        `json:"order"`
+	Headline       string     `json:"headline"`
+	EventLink      string     `json:"event_link"`
+	SmallImageLink string     `json:"image_link"`
+	Weekday        string     `json:"week_day"`
+	Date           string     `json:"date"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	StoreLink      string     `json:"store_ink"`
+	InStock        bool       `json:"in_stock"`
+	BulletPoints   []string   `json:"bullet_points"`
+	Source         string     `json:"source,omitempty"`
+	Venue          string     `json:"venue,omitempty"`
+	Archived       bool       `json:"archived,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at,omitempty"`
 }
 
-// EventDetails is a more rich information related one to one to event and fetched from the events' page
+// EventDetails is a more rich information related one to one to event and fetched from the events' page,
+// DoorsOpen and SetTimes are parsed from PlayTimes when the day of the event is known
 type EventDetails struct {
-	EventID     string            `json:"event_id"`
-	Description []string          `json:"summary"`
-	ImageLink   string            `json:"image_link"`
-	ProductInfo map[string]string `json:"product_info"`
-	PlayTimes   []string          `json:"play_times"`
-	Tickets     EventTickets      `json:"tickets"`
-	DoorPrice   DoorPrice         `json:"door_price"`
-	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
+	EventID         string            `json:"event_id"`
+	Description     []string          `json:"summary"`
+	ImageLink       string            `json:"image_link"`
+	ProductInfo     map[string]string `json:"product_info"`
+	PlayTimes       []string          `json:"play_times"`
+	DoorsOpen       *time.Time        `json:"doors_open,omitempty"`
+	SetTimes        []SetTime         `json:"set_times,omitempty"`
+	Tickets         EventTickets      `json:"tickets"`
+	DoorPrice       DoorPrice         `json:"door_price"`
+	ParsedDoorPrice *Price            `json:"parsed_door_price,omitempty"`
+	UpdatedAt       time.Time         `json:"updated_at,omitempty"`
+	Origin          Origin            `json:"-"`
+}
+
+// SetTime is a parsed play time for a single act
+type SetTime struct {
+	Act      string    `json:"act"`
+	StartsAt time.Time `json:"starts_at"`
 }
 
-// Ticket single ticket for event
+// Ticket single ticket for event, Description is the ticket tier and ParsedPrice is nil when Price could not be parsed
 type Ticket struct {
 	Description string `json:"description"`
 	Price       string `json:"price"`
+	ParsedPrice *Price `json:"parsed_price,omitempty"`
 }
 
 // EventTickets tickets for the event, can be emppty as well
@@ -41,7 +68,7 @@ type EventTickets struct {
 	Tickets []Ticket `json:"tickets"`
 }
 
-// DoorPrice is alias of string for now
+// DoorPrice is alias of string for the original text, parsed price is in EventDetails.ParsedDoorPrice
 type DoorPrice = string
 
 // EventAscii is a container for events' image which is converted into string
@@ -49,10 +76,12 @@ type EventAscii struct {
 	Ascii     string    `json:"ascii"`
 	EventID   string    `json:"event_id"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	Origin    Origin    `json:"-"`
 }
 
 // Events simple wrapper for event list with a timestamp
 type Events struct {
 	Events    []Event   `json:"events"`
 	UpdatedAt time.Time `json:"updated_at,omitempty"`
+	Origin    Origin    `json:"-"`
 }
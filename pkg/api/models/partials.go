@@ -34,3 +34,15 @@ func (ed EventDetails) ImageURL() string {
 func (ea EventAscii) ID() string {
 	return ea.EventID
 }
+
+// Listed returns the events which are still listed by the source
+func (e Events) Listed() Events {
+	listed := e
+	listed.Events = nil
+	for _, evt := range e.Events {
+		if !evt.Archived {
+			listed.Events = append(listed.Events, evt)
+		}
+	}
+	return listed
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Price is a parsed price with the amount in cents, Text contains the original scraped value
+type Price struct {
+	Cents    int64  `json:"cents"`
+	Currency string `json:"currency"`
+	Text     string `json:"text"`
+}
+
+// String formats the price in the way it is shown on the source
+func (p Price) String() string {
+	amount := fmt.Sprintf("%d,%02d", p.Cents/100, p.Cents%100)
+	amount = strings.TrimSuffix(amount, ",00")
+	switch p.Currency {
+	case "EUR":
+		return amount + " €"
+	default:
+		return amount + " " + p.Currency
+	}
+}
+
+// Same checks if the prices have the same amount in the same currency regardless of the original text
+func (p Price) Same(other Price) bool {
+	return p.Cents == other.Cents && p.Currency == other.Currency
+}
+
+// LowestPrice returns the cheapest parsed ticket or door price for the event, nil if there are none
+func (ed EventDetails) LowestPrice() *Price {
+	var lowest *Price
+	candidates := []*Price{ed.ParsedDoorPrice}
+	for _, t := range ed.Tickets.Tickets {
+		candidates = append(candidates, t.ParsedPrice)
+	}
+	for _, c := range candidates {
+		if c != nil && (lowest == nil || c.Cents < lowest.Cents) {
+			lowest = c
+		}
+	}
+	return lowest
+}
@@ -15,6 +15,8 @@ const (
 	_ TypeOption = iota
 	UseOffline
 	UseOnline
+	UseStore
+	UseHybrid
 )
 
 // Has check if option is in the option list
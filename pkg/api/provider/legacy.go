@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+)
+
+// LegacyProvider is the provider api without context support
+type LegacyProvider interface {
+	GetEvents(opts ...options.ProviderOption) (*models.Events, error)
+	GetAscii(eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error)
+	GetDetails(eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error)
+}
+
+type legacyShim struct {
+	p Provider
+}
+
+// AsLegacy wraps the provider into the api without context, calls are done with the background context
+func AsLegacy(p Provider) LegacyProvider {
+	return legacyShim{p: p}
+}
+
+func (l legacyShim) GetEvents(opts ...options.ProviderOption) (*models.Events, error) {
+	return l.p.GetEvents(context.Background(), opts...)
+}
+
+func (l legacyShim) GetAscii(eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error) {
+	return l.p.GetAscii(context.Background(), eventID, imageURL, opts...)
+}
+
+func (l legacyShim) GetDetails(eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error) {
+	return l.p.GetDetails(context.Background(), eventID, eventURL, opts...)
+}
+
+type contextShim struct {
+	p LegacyProvider
+}
+
+// FromLegacy wraps a provider without context support, the ctx is only checked before the calls as the
+// underlying calls can not be cancelled
+func FromLegacy(p LegacyProvider) Provider {
+	return contextShim{p: p}
+}
+
+func (c contextShim) GetEvents(ctx context.Context, opts ...options.ProviderOption) (*models.Events, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.p.GetEvents(opts...)
+}
+
+func (c contextShim) GetAscii(ctx context.Context, eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error) {
+	if err := ctx.Err(); err != nil {
+		return models.EventAscii{}, err
+	}
+	return c.p.GetAscii(eventID, imageURL, opts...)
+}
+
+func (c contextShim) GetDetails(ctx context.Context, eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error) {
+	if err := ctx.Err(); err != nil {
+		return models.EventDetails{}, err
+	}
+	return c.p.GetDetails(eventID, eventURL, opts...)
+}
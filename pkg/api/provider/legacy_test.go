@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"slices"
+	"testing"
+)
+
+// recordingProvider answers with the arguments it was called with
+type recordingProvider struct {
+	calls int
+	ctxs  []context.Context
+	opts  [][]options.ProviderOption
+}
+
+func (r *recordingProvider) record(ctx context.Context, opts []options.ProviderOption) {
+	r.calls++
+	r.ctxs = append(r.ctxs, ctx)
+	r.opts = append(r.opts, opts)
+}
+
+func (r *recordingProvider) GetEvents(ctx context.Context, opts ...options.ProviderOption) (*models.Events, error) {
+	r.record(ctx, opts)
+	return &models.Events{Events: []models.Event{{Id: "1"}}}, nil
+}
+
+func (r *recordingProvider) GetAscii(ctx context.Context, eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error) {
+	r.record(ctx, opts)
+	return models.EventAscii{EventID: eventID, Ascii: imageURL}, nil
+}
+
+func (r *recordingProvider) GetDetails(ctx context.Context, eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error) {
+	r.record(ctx, opts)
+	return models.EventDetails{EventID: eventID, ImageLink: eventURL}, errors.New("details failed")
+}
+
+func TestLegacyRoundTrip(t *testing.T) {
+	rec := &recordingProvider{}
+	p := FromLegacy(AsLegacy(rec))
+	ctx := context.Background()
+
+	events, err := p.GetEvents(ctx, options.SkipCache)
+	if err != nil || len(events.Events) != 1 || events.Events[0].Id != "1" {
+		t.Errorf("unexpected events %+v, err: %v", events, err)
+	}
+	ascii, err := p.GetAscii(ctx, "1", "img")
+	if err != nil || ascii.EventID != "1" || ascii.Ascii != "img" {
+		t.Errorf("unexpected ascii %+v, err: %v", ascii, err)
+	}
+	details, err := p.GetDetails(ctx, "1", "url")
+	if err == nil || err.Error() != "details failed" {
+		t.Errorf("expected the error of the provider, got: %v", err)
+	}
+	if details.EventID != "1" || details.ImageLink != "url" {
+		t.Errorf("unexpected details %+v", details)
+	}
+
+	if rec.calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", rec.calls)
+	}
+	if !slices.Equal(rec.opts[0], []options.ProviderOption{options.SkipCache}) || len(rec.opts[1]) != 0 {
+		t.Errorf("options were not passed through: %v", rec.opts)
+	}
+	for i, c := range rec.ctxs {
+		if c == nil || c.Err() != nil {
+			t.Errorf("call %d got an unusable context", i)
+		}
+	}
+}
+
+func TestFromLegacyCancelled(t *testing.T) {
+	rec := &recordingProvider{}
+	p := FromLegacy(AsLegacy(rec))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := p.GetEvents(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got: %v", err)
+	}
+	if _, err := p.GetAscii(ctx, "1", "img"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got: %v", err)
+	}
+	if _, err := p.GetDetails(ctx, "1", "url"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got: %v", err)
+	}
+	if rec.calls != 0 {
+		t.Errorf("cancelled calls reached the provider %d times", rec.calls)
+	}
+}
@@ -1,24 +1,31 @@
 package provider
 
 import (
+	"context"
 	"errors"
 	"github.com/johannessarpola/lutakkols/pkg/api/internal/builder"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
 	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"time"
 )
 
 type Config struct {
 	EventsSourceURL    string
+	Sources            []string
 	DefaultOpts        []options.ProviderOption
 	EventSourceFsPath  string
 	EventDetailsFsPath string
+	EventAsciiFsPath   string
+	StorePath          string
 	AsciiGen           func(string, string) string
+	FetchTimeout       time.Duration
 }
 
+// Provider provides the events, pending work is cancelled when the ctx is done
 type Provider interface {
-	GetEvents(opts ...options.ProviderOption) (*models.Events, error)
-	GetAscii(eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error)
-	GetDetails(eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error)
+	GetEvents(ctx context.Context, opts ...options.ProviderOption) (*models.Events, error)
+	GetAscii(ctx context.Context, eventID string, imageURL string, opts ...options.ProviderOption) (models.EventAscii, error)
+	GetDetails(ctx context.Context, eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error)
 }
 
 type Downloader interface {
@@ -31,6 +38,7 @@ func New(config *Config, opt options.TypeOption) (Provider, error) {
 	case options.UseOnline:
 		b := (&builder.OnlineBuilder{}).
 			WitEventsSourceURL(config.EventsSourceURL).
+			WithSources(config.Sources...).
 			WithDefaultOpts(config.DefaultOpts...)
 		return b.Build()
 	case options.UseOffline:
@@ -38,8 +46,26 @@ func New(config *Config, opt options.TypeOption) (Provider, error) {
 			WithEventSourceFsPath(config.EventSourceFsPath).
 			WithDefaultOpts(config.DefaultOpts...).
 			WithEventDetailsFsPath(config.EventDetailsFsPath).
+			WithEventAsciiFsPath(config.EventAsciiFsPath).
 			WithAsciiGen(config.AsciiGen)
 		return b.Build()
+	case options.UseStore:
+		b := (&builder.StoreBuilder{}).
+			WithStorePath(config.StorePath).
+			WithDefaultOpts(config.DefaultOpts...).
+			WithAsciiGen(config.AsciiGen)
+		return b.Build()
+	case options.UseHybrid:
+		b := (&builder.HybridBuilder{}).
+			WitEventsSourceURL(config.EventsSourceURL).
+			WithSources(config.Sources...).
+			WithEventSourceFsPath(config.EventSourceFsPath).
+			WithEventDetailsFsPath(config.EventDetailsFsPath).
+			WithEventAsciiFsPath(config.EventAsciiFsPath).
+			WithDefaultOpts(config.DefaultOpts...).
+			WithAsciiGen(config.AsciiGen).
+			WithTimeout(config.FetchTimeout)
+		return b.Build()
 	default:
 		return nil, errors.New("invalid option")
 	}
@@ -0,0 +1,169 @@
+// Package dates contains the parsing of the free-text dates and play times scraped from the source into timestamps
+package dates
+
+import (
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // embedded so that the location can be loaded in minimal containers
+)
+
+// Location is the timezone in which the events take place
+var Location = func() *time.Location {
+	loc, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		return time.FixedZone("EET", 2*60*60)
+	}
+	return loc
+}()
+
+// pastGraceDays is how far in the past a date without year can be before it is moved to the next year
+const pastGraceDays = 60
+
+// lateNightHour is the hour before which the play times are considered to be on the following day
+const lateNightHour = 6
+
+var (
+	isoDateRe = regexp.MustCompile(`(\d{4})-(\d{1,2})-(\d{1,2})`)
+	dateRe    = regexp.MustCompile(`(\d{1,2})\.(\d{1,2})\.(\d{2,4})?`)
+	clockRe   = regexp.MustCompile(`\b(\d{1,2})[:.](\d{2})\b(\.\d)?`)
+	klo       = regexp.MustCompile(`(?i)\bklo\b`)
+)
+
+// UnparseableError is returned when the value does not contain anything resembling a date or time
+type UnparseableError struct {
+	Value string
+	Kind  string
+}
+
+func (e UnparseableError) Error() string {
+	return fmt.Sprintf("could not parse %s from '%s'", e.Kind, e.Value)
+}
+
+// ParseDay parses a date like "18.10.", "18.10.2024" or "2024-10-18" into midnight of that day in Location.
+// When the year is missing it is inferred from the reference time, dates more than pastGraceDays before it
+// are in the following year so that a "3.1." seen in December will be in January.
+func ParseDay(value string, ref time.Time) (time.Time, error) {
+	if m := isoDateRe.FindStringSubmatch(value); m != nil {
+		y, _ := strconv.Atoi(m[1])
+		return day(y, m[2], m[3])
+	}
+
+	m := dateRe.FindStringSubmatch(value)
+	if m == nil {
+		return time.Time{}, UnparseableError{Value: value, Kind: "date"}
+	}
+
+	if len(m[3]) > 0 {
+		y, _ := strconv.Atoi(m[3])
+		if y < 100 {
+			y += 2000
+		}
+		return day(y, m[2], m[1])
+	}
+
+	ref = ref.In(Location)
+	t, err := day(ref.Year(), m[2], m[1])
+	if err != nil {
+		return t, err
+	}
+	// listed events are upcoming so dates clearly in the past are on the other side of the new year
+	if t.Before(ref.AddDate(0, 0, -pastGraceDays)) {
+		t = t.AddDate(1, 0, 0)
+	}
+	return t, nil
+}
+
+func day(year int, month string, dayOfMonth string) (time.Time, error) {
+	mo, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(dayOfMonth)
+	if mo < 1 || mo > 12 || d < 1 || d > 31 {
+		return time.Time{}, UnparseableError{Value: fmt.Sprintf("%s.%s.%d", dayOfMonth, month, year), Kind: "date"}
+	}
+	t := time.Date(year, time.Month(mo), d, 0, 0, 0, 0, Location)
+	if t.Day() != d {
+		return time.Time{}, UnparseableError{Value: fmt.Sprintf("%s.%s.%d", dayOfMonth, month, year), Kind: "date"}
+	}
+	return t, nil
+}
+
+// clocks returns the submatch indexes of the clock times in the value, a match followed by ".<digit>" is a date like
+// "18.10.2024" and not a time
+func clocks(value string) [][]int {
+	var found [][]int
+	for _, loc := range clockRe.FindAllStringSubmatchIndex(value, -1) {
+		if loc[6] < 0 {
+			found = append(found, loc)
+		}
+	}
+	return found
+}
+
+// ParseClock finds the first clock time like "19:00" or "klo 19.00" in the value and returns it on the given day
+// together with the rest of the value. Times before early morning are moved to the following day.
+func ParseClock(value string, on time.Time) (time.Time, string, error) {
+	found := clocks(value)
+	if len(found) == 0 {
+		return time.Time{}, value, UnparseableError{Value: value, Kind: "time"}
+	}
+	loc := found[0]
+	h, _ := strconv.Atoi(value[loc[2]:loc[3]])
+	m, _ := strconv.Atoi(value[loc[4]:loc[5]])
+	if h > 23 || m > 59 {
+		return time.Time{}, value, UnparseableError{Value: value, Kind: "time"}
+	}
+
+	on = on.In(Location)
+	t := time.Date(on.Year(), on.Month(), on.Day(), h, m, 0, 0, Location)
+	if h < lateNightHour {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	// drop the time and the possible end time of a range like 21:00-22:00
+	rest := value
+	for i := len(found) - 1; i >= 0; i-- {
+		rest = rest[:found[i][0]] + " " + rest[found[i][1]:]
+	}
+	rest = klo.ReplaceAllString(rest, "")
+	rest = strings.Trim(strings.TrimSpace(rest), " -–:|,")
+	return t, strings.TrimSpace(rest), nil
+}
+
+// isDoors checks if the play time line is for the doors opening
+func isDoors(act string) bool {
+	l := strings.ToLower(act)
+	return strings.HasPrefix(l, "ovet") || strings.HasPrefix(l, "doors")
+}
+
+// Schedule parses the play times on the given day into doors opening and set times, the lines which could not be
+// parsed are returned as is so that they can be reported
+func Schedule(on time.Time, playTimes []string) (*time.Time, []models.SetTime, []string) {
+	var (
+		doors    *time.Time
+		sets     []models.SetTime
+		unparsed []string
+	)
+
+	for _, pt := range playTimes {
+		t, act, err := ParseClock(pt, on)
+		if err != nil {
+			if len(strings.TrimSpace(pt)) > 0 {
+				unparsed = append(unparsed, pt)
+			}
+			continue
+		}
+		if isDoors(act) {
+			doors = &t
+			continue
+		}
+		sets = append(sets, models.SetTime{
+			Act:      act,
+			StartsAt: t,
+		})
+	}
+
+	return doors, sets, unparsed
+}
@@ -0,0 +1,103 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDay(t *testing.T) {
+	ref := time.Date(2024, 12, 20, 12, 0, 0, 0, Location)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"21.12.", time.Date(2024, 12, 21, 0, 0, 0, 0, Location)},
+		{"3.1.", time.Date(2025, 1, 3, 0, 0, 0, 0, Location)},
+		{"1.7.", time.Date(2025, 7, 1, 0, 0, 0, 0, Location)},
+		{"15.6.", time.Date(2025, 6, 15, 0, 0, 0, 0, Location)},
+		{"1.12.", time.Date(2024, 12, 1, 0, 0, 0, 0, Location)},
+		{"18.10.2023", time.Date(2023, 10, 18, 0, 0, 0, 0, Location)},
+		{"18.10.23", time.Date(2023, 10, 18, 0, 0, 0, 0, Location)},
+		{"2024-05-30", time.Date(2024, 5, 30, 0, 0, 0, 0, Location)},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDay(tt.value, ref)
+		if err != nil {
+			t.Errorf("err parsing %s: %s", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parsing %s got %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	for _, v := range []string{"", "tba", "31.2.", "40.1."} {
+		if _, err := ParseDay(v, ref); err == nil {
+			t.Errorf("expected err parsing '%s'", v)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	on := time.Date(2024, 10, 18, 0, 0, 0, 0, Location)
+	doors, sets, unparsed := Schedule(on, []string{
+		"Ovet klo 19.00",
+		"Opener 20:00",
+		"22:00 – Headliner",
+		"Late Act 00:30",
+		"Aikataulu tarkentuu",
+		"Soolokeikka 18.10.2024",
+	})
+
+	if doors == nil || doors.Hour() != 19 {
+		t.Errorf("doors not parsed: %v", doors)
+	}
+	if len(sets) != 3 {
+		t.Fatalf("expected 3 sets, got %d", len(sets))
+	}
+	if sets[0].Act != "Opener" || sets[0].StartsAt.Hour() != 20 {
+		t.Errorf("invalid set: %+v", sets[0])
+	}
+	if sets[1].Act != "Headliner" {
+		t.Errorf("invalid act: '%s'", sets[1].Act)
+	}
+	if sets[2].StartsAt.Day() != 19 {
+		t.Errorf("late set should be on the next day: %s", sets[2].StartsAt)
+	}
+	if len(unparsed) != 2 {
+		t.Errorf("expected 1 unparsed, got %v", unparsed)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	on := time.Date(2024, 10, 18, 0, 0, 0, 0, Location)
+
+	tests := []struct {
+		value string
+		hour  int
+		min   int
+		rest  string
+	}{
+		{"klo 19.00 Ovet", 19, 0, "Ovet"},
+		{"21:00-22:00 Headliner", 21, 0, "Headliner"},
+		{"18.10.2024 Opener 20:30", 20, 30, "18.10.2024 Opener"},
+	}
+	for _, tt := range tests {
+		got, rest, err := ParseClock(tt.value, on)
+		if err != nil {
+			t.Errorf("err parsing %s: %s", tt.value, err)
+			continue
+		}
+		if got.Hour() != tt.hour || got.Minute() != tt.min || rest != tt.rest {
+			t.Errorf("parsing %s got %s '%s'", tt.value, got, rest)
+		}
+	}
+
+	for _, v := range []string{"18.10.2024", "pe 1.11.24", "Liput 25.50€"} {
+		if _, _, err := ParseClock(v, on); err == nil {
+			t.Errorf("expected err parsing '%s'", v)
+		}
+	}
+}
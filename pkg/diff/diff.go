@@ -0,0 +1,184 @@
+// Package diff compares two synced snapshots and reports what changed between them for people following the gigs
+package diff
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/snapshot"
+	"slices"
+	"strings"
+)
+
+// cancelledMarkers are looked for in the headline and bullet points of a cancelled event
+var cancelledMarkers = []string{"peruttu", "peruutettu", "cancelled", "canceled"}
+
+// EventRef identifies the event in the report
+type EventRef struct {
+	ID       string `json:"id"`
+	Headline string `json:"headline"`
+	Date     string `json:"date"`
+	Link     string `json:"link"`
+}
+
+// PriceChange is a changed, added or removed ticket tier, Before or After is empty when the tier was added or removed
+type PriceChange struct {
+	Event  EventRef `json:"event"`
+	Tier   string   `json:"tier"`
+	Before string   `json:"before,omitempty"`
+	After  string   `json:"after,omitempty"`
+}
+
+// PlayTimeChange contains the play times of an event before and after
+type PlayTimeChange struct {
+	Event  EventRef `json:"event"`
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+// Report contains the changes between two snapshots
+type Report struct {
+	New         []EventRef       `json:"new"`
+	Removed     []EventRef       `json:"removed"`
+	Cancelled   []EventRef       `json:"cancelled"`
+	SoldOut     []EventRef       `json:"sold_out"`
+	BackInStock []EventRef       `json:"back_in_stock"`
+	Prices      []PriceChange    `json:"prices"`
+	PlayTimes   []PlayTimeChange `json:"play_times"`
+}
+
+// Empty tells if nothing changed
+func (r Report) Empty() bool {
+	return len(r.New) == 0 && len(r.Removed) == 0 && len(r.Cancelled) == 0 && len(r.SoldOut) == 0 &&
+		len(r.BackInStock) == 0 && len(r.Prices) == 0 && len(r.PlayTimes) == 0
+}
+
+func ref(e models.Event) EventRef {
+	return EventRef{ID: e.ID(), Headline: e.Headline, Date: e.Date, Link: e.EventLink}
+}
+
+func cancelled(e models.Event) bool {
+	text := strings.ToLower(e.Headline + " " + strings.Join(e.BulletPoints, " "))
+	for _, m := range cancelledMarkers {
+		if strings.Contains(text, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func listed(events []models.Event) map[string]models.Event {
+	m := map[string]models.Event{}
+	for _, e := range events {
+		if !e.Archived {
+			m[e.ID()] = e
+		}
+	}
+	return m
+}
+
+func detailsByID(details []models.EventDetails) map[string]models.EventDetails {
+	m := map[string]models.EventDetails{}
+	for _, ed := range details {
+		m[ed.ID()] = ed
+	}
+	return m
+}
+
+// Compare compares the listed events of the snapshots, the report follows the order of the events in after and
+// removed events the order in before
+func Compare(before snapshot.Snapshot, after snapshot.Snapshot) Report {
+	var r Report
+	prevEvents := listed(before.Events)
+	curEvents := listed(after.Events)
+	prevDetails := detailsByID(before.Details)
+	curDetails := detailsByID(after.Details)
+
+	for _, e := range before.Events {
+		if _, ok := curEvents[e.ID()]; !ok && !e.Archived {
+			r.Removed = append(r.Removed, ref(e))
+		}
+	}
+
+	for _, e := range after.Events {
+		if e.Archived {
+			continue
+		}
+		prev, ok := prevEvents[e.ID()]
+		if !ok {
+			r.New = append(r.New, ref(e))
+			continue
+		}
+
+		if cancelled(e) && !cancelled(prev) {
+			r.Cancelled = append(r.Cancelled, ref(e))
+		}
+		if prev.InStock && !e.InStock {
+			r.SoldOut = append(r.SoldOut, ref(e))
+		} else if !prev.InStock && e.InStock {
+			r.BackInStock = append(r.BackInStock, ref(e))
+		}
+
+		pd, okPrev := prevDetails[e.ID()]
+		cd, okCur := curDetails[e.ID()]
+		if !okPrev || !okCur {
+			continue
+		}
+		r.Prices = append(r.Prices, comparePrices(ref(e), pd, cd)...)
+		if !slices.Equal(pd.PlayTimes, cd.PlayTimes) {
+			r.PlayTimes = append(r.PlayTimes, PlayTimeChange{Event: ref(e), Before: pd.PlayTimes, After: cd.PlayTimes})
+		}
+	}
+	return r
+}
+
+// doorTier is the name used for the door price in the price changes
+const doorTier = "Ovelta"
+
+type tier struct {
+	name   string
+	text   string
+	parsed *models.Price
+}
+
+func tiers(ed models.EventDetails) []tier {
+	var ts []tier
+	for _, t := range ed.Tickets.Tickets {
+		ts = append(ts, tier{name: t.Description, text: t.Price, parsed: t.ParsedPrice})
+	}
+	if len(ed.DoorPrice) > 0 {
+		ts = append(ts, tier{name: doorTier, text: ed.DoorPrice, parsed: ed.ParsedDoorPrice})
+	}
+	return ts
+}
+
+// samePrice compares the parsed prices if both could be parsed and otherwise the texts
+func samePrice(a tier, b tier) bool {
+	if a.parsed != nil && b.parsed != nil {
+		return a.parsed.Same(*b.parsed)
+	}
+	return strings.TrimSpace(a.text) == strings.TrimSpace(b.text)
+}
+
+func comparePrices(e EventRef, before models.EventDetails, after models.EventDetails) []PriceChange {
+	var changes []PriceChange
+	prev := map[string]tier{}
+	for _, t := range tiers(before) {
+		prev[t.name] = t
+	}
+
+	seen := map[string]bool{}
+	for _, t := range tiers(after) {
+		seen[t.name] = true
+		p, ok := prev[t.name]
+		if !ok {
+			changes = append(changes, PriceChange{Event: e, Tier: t.name, After: t.text})
+		} else if !samePrice(p, t) {
+			changes = append(changes, PriceChange{Event: e, Tier: t.name, Before: p.text, After: t.text})
+		}
+	}
+	for _, t := range tiers(before) {
+		if !seen[t.name] {
+			changes = append(changes, PriceChange{Event: e, Tier: t.name, Before: t.text})
+		}
+	}
+	return changes
+}
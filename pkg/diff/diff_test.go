@@ -0,0 +1,149 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/snapshot"
+	"strings"
+	"testing"
+)
+
+func price(cents int64, text string) *models.Price {
+	return &models.Price{Cents: cents, Currency: "EUR", Text: text}
+}
+
+func snapshots() (snapshot.Snapshot, snapshot.Snapshot) {
+	before := snapshot.Snapshot{
+		Events: []models.Event{
+			{Id: "stays", Headline: "Stays", Date: "18.10.", InStock: true},
+			{Id: "removed", Headline: "Removed", Date: "19.10."},
+			{Id: "cancelled", Headline: "Band", Date: "20.10.", InStock: true},
+			{Id: "restocked", Headline: "Restocked", Date: "21.10."},
+			{Id: "old", Headline: "Old", Archived: true},
+		},
+		Details: []models.EventDetails{
+			{
+				EventID:   "stays",
+				PlayTimes: []string{"Ovet 19.00"},
+				Tickets: models.EventTickets{Tickets: []models.Ticket{
+					{Description: "Ennakko", Price: "25,00 €", ParsedPrice: price(2500, "25,00 €")},
+					{Description: "VIP", Price: "50 €", ParsedPrice: price(5000, "50 €")},
+				}},
+				DoorPrice:       "30 €",
+				ParsedDoorPrice: price(3000, "30 €"),
+			},
+		},
+	}
+	after := snapshot.Snapshot{
+		Events: []models.Event{
+			{Id: "new", Headline: "New", Date: "17.10.", EventLink: "https://example.com/new"},
+			{Id: "stays", Headline: "Stays", Date: "18.10.", InStock: false},
+			{Id: "cancelled", Headline: "PERUTTU: Band", Date: "20.10.", InStock: true},
+			{Id: "restocked", Headline: "Restocked", Date: "21.10.", InStock: true},
+			{Id: "removed", Headline: "Removed", Archived: true},
+		},
+		Details: []models.EventDetails{
+			{
+				EventID:   "stays",
+				PlayTimes: []string{"Ovet 20.00"},
+				Tickets: models.EventTickets{Tickets: []models.Ticket{
+					{Description: "Ennakko", Price: "25 €", ParsedPrice: price(2500, "25 €")},
+					{Description: "Opiskelija", Price: "20 €", ParsedPrice: price(2000, "20 €")},
+				}},
+				DoorPrice:       "35 €",
+				ParsedDoorPrice: price(3500, "35 €"),
+			},
+		},
+	}
+	return before, after
+}
+
+func ids(refs []EventRef) string {
+	var rs []string
+	for _, r := range refs {
+		rs = append(rs, r.ID)
+	}
+	return strings.Join(rs, ",")
+}
+
+func TestCompare(t *testing.T) {
+	r := Compare(snapshots())
+
+	checks := map[string][2]string{
+		"new":           {ids(r.New), "new"},
+		"removed":       {ids(r.Removed), "removed"},
+		"cancelled":     {ids(r.Cancelled), "cancelled"},
+		"sold out":      {ids(r.SoldOut), "stays"},
+		"back in stock": {ids(r.BackInStock), "restocked"},
+	}
+	for name, c := range checks {
+		if c[0] != c[1] {
+			t.Errorf("%s: expected %s, got %s", name, c[1], c[0])
+		}
+	}
+
+	// the ticket with only the text reformatted is not a change
+	want := []PriceChange{
+		{Tier: "Opiskelija", After: "20 €"},
+		{Tier: doorTier, Before: "30 €", After: "35 €"},
+		{Tier: "VIP", Before: "50 €"},
+	}
+	if len(r.Prices) != len(want) {
+		t.Fatalf("expected %d price changes, got %+v", len(want), r.Prices)
+	}
+	for i, w := range want {
+		p := r.Prices[i]
+		if p.Tier != w.Tier || p.Before != w.Before || p.After != w.After || p.Event.ID != "stays" {
+			t.Errorf("unexpected price change %+v", p)
+		}
+	}
+
+	if len(r.PlayTimes) != 1 || r.PlayTimes[0].After[0] != "Ovet 20.00" {
+		t.Errorf("unexpected play time changes %+v", r.PlayTimes)
+	}
+
+	if Compare(snapshots()).Empty() {
+		t.Errorf("report should not be empty")
+	}
+	before, _ := snapshots()
+	if !Compare(before, before).Empty() {
+		t.Errorf("same snapshot should not have changes")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := Compare(snapshots())
+
+	var text bytes.Buffer
+	if err := r.Write(&text, Text); err != nil {
+		t.Fatalf("err writing text: %s", err)
+	}
+	if !strings.Contains(text.String(), "New gigs (1):\n  - New (17.10.)") ||
+		!strings.Contains(text.String(), "Stays (18.10.): Ovelta 30 € → 35 €") {
+		t.Errorf("unexpected text report:\n%s", text.String())
+	}
+
+	var md bytes.Buffer
+	_ = r.Write(&md, Markdown)
+	if !strings.Contains(md.String(), "### New gigs (1)\n- [**New**](https://example.com/new) (17.10.)") {
+		t.Errorf("unexpected markdown report:\n%s", md.String())
+	}
+
+	var js bytes.Buffer
+	_ = r.Write(&js, Json)
+	var decoded Report
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || len(decoded.Prices) != 3 {
+		t.Errorf("json report could not be read back: %v", err)
+	}
+
+	var empty bytes.Buffer
+	_ = Report{}.Write(&empty, Text)
+	if empty.String() != "No changes\n" {
+		t.Errorf("unexpected empty report %q", empty.String())
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("expected err for unknown format")
+	}
+}
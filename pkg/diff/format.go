@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format of the report output
+type Format string
+
+const (
+	Text     Format = "text"
+	Json     Format = "json"
+	Markdown Format = "markdown"
+)
+
+// ParseFormat parses the format name, md is accepted for markdown
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "text", "":
+		return Text, nil
+	case "json":
+		return Json, nil
+	case "markdown", "md":
+		return Markdown, nil
+	default:
+		return "", fmt.Errorf("unknown format %s, expected text, json or markdown", name)
+	}
+}
+
+// Write writes the report in the format
+func (r Report) Write(w io.Writer, f Format) error {
+	switch f {
+	case Json:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case Markdown:
+		return r.write(w, markdownStyle)
+	default:
+		return r.write(w, textStyle)
+	}
+}
+
+type style struct {
+	heading func(string) string
+	event   func(EventRef) string
+	item    string
+}
+
+var textStyle = style{
+	heading: func(s string) string { return s + ":" },
+	event: func(e EventRef) string {
+		return fmt.Sprintf("%s (%s)", e.Headline, e.Date)
+	},
+	item: "  - ",
+}
+
+var markdownStyle = style{
+	heading: func(s string) string { return "### " + s },
+	event: func(e EventRef) string {
+		if len(e.Link) == 0 {
+			return fmt.Sprintf("**%s** (%s)", e.Headline, e.Date)
+		}
+		return fmt.Sprintf("[**%s**](%s) (%s)", e.Headline, e.Link, e.Date)
+	},
+	item: "- ",
+}
+
+func (r Report) write(w io.Writer, s style) error {
+	var sb strings.Builder
+	if r.Empty() {
+		sb.WriteString("No changes\n")
+	}
+
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(s.heading(fmt.Sprintf("%s (%d)", title, len(lines))))
+		sb.WriteString("\n")
+		for _, l := range lines {
+			sb.WriteString(s.item)
+			sb.WriteString(l)
+			sb.WriteString("\n")
+		}
+	}
+	events := func(refs []EventRef) []string {
+		var lines []string
+		for _, e := range refs {
+			lines = append(lines, s.event(e))
+		}
+		return lines
+	}
+
+	section("New gigs", events(r.New))
+	section("Cancelled", events(r.Cancelled))
+	section("Removed", events(r.Removed))
+	section("Sold out", events(r.SoldOut))
+	section("Back in stock", events(r.BackInStock))
+
+	var prices []string
+	for _, p := range r.Prices {
+		change := fmt.Sprintf("%s → %s", p.Before, p.After)
+		if len(p.Before) == 0 {
+			change = "new " + p.After
+		} else if len(p.After) == 0 {
+			change = "removed " + p.Before
+		}
+		prices = append(prices, fmt.Sprintf("%s: %s %s", s.event(p.Event), p.Tier, change))
+	}
+	section("Price changes", prices)
+
+	var playTimes []string
+	for _, p := range r.PlayTimes {
+		playTimes = append(playTimes, fmt.Sprintf("%s: %s", s.event(p.Event), strings.Join(p.After, ", ")))
+	}
+	section("Changed play times", playTimes)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"context"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/provider"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/workset"
+	"time"
+)
+
+// collectWorkers is how many details are fetched at a time so that an online provider is not flooded
+const collectWorkers = 4
+
+// collectTimeout is how long fetching the details of a single event can take
+const collectTimeout = 20 * time.Second
+
+// Collect gets the details of the events from the provider, the events whose details can not be fetched are
+// exported without them. Only a done ctx is returned as an error.
+func Collect(ctx context.Context, p provider.Provider, events []models.Event) ([]Item, error) {
+	tasks := make([]workset.Task[models.EventDetails], len(events))
+	for i, e := range events {
+		tasks[i] = func() (models.EventDetails, error) {
+			return p.GetDetails(ctx, e.ID(), e.EventURL())
+		}
+	}
+	results := workset.NewWorkSet(tasks, collectWorkers, collectTimeout).Collect()
+
+	items := make([]Item, len(events))
+	for i, e := range events {
+		items[i] = Item{Event: e}
+	}
+	if err := ctx.Err(); err != nil {
+		return items, err
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			logger.Log.Warnf("exporting event %s without details: %v", events[r.Index].ID(), r.Error)
+			continue
+		}
+		ed := r.Value
+		items[r.Index].Details = &ed
+	}
+	return items, nil
+}
@@ -0,0 +1,238 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+// FeedFormat is the syndication format of the feed
+type FeedFormat string
+
+const (
+	Atom FeedFormat = "atom"
+	RSS  FeedFormat = "rss"
+)
+
+// ParseFeedFormat parses the name of the feed format
+func ParseFeedFormat(value string) (FeedFormat, error) {
+	switch f := FeedFormat(strings.ToLower(value)); f {
+	case Atom, RSS:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown feed format %q, expected atom or rss", value)
+	}
+}
+
+// ContentType is the media type of the feed format
+func (f FeedFormat) ContentType() string {
+	if f == RSS {
+		return "application/rss+xml; charset=utf-8"
+	}
+	return "application/atom+xml; charset=utf-8"
+}
+
+// FeedInfo describes the feed itself, Link is the site the events are from and Self where the feed is published
+type FeedInfo struct {
+	Title   string
+	Link    string
+	Self    string
+	Updated time.Time
+}
+
+// EntryID is the stable id of the feed entry of the event
+func EntryID(id string) string {
+	return "urn:lutakkols:event:" + id
+}
+
+// WriteFeed writes the items as an Atom or RSS feed, the entries are updated at the time of their event
+func WriteFeed(w io.Writer, format FeedFormat, info FeedInfo, items []Item) error {
+	var doc any
+	switch format {
+	case Atom:
+		doc = atomFeed(info, items)
+	case RSS:
+		doc = rssFeed(info, items)
+	default:
+		return fmt.Errorf("unknown feed format %q", format)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Updated string       `xml:"updated"`
+	Links   []atomLink   `xml:"link"`
+	Content *atomContent `xml:"content,omitempty"`
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  string      `xml:"author>name"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+func atomFeed(info FeedInfo, items []Item) atom {
+	feed := atom{
+		ID:      "urn:lutakkols:feed:" + info.Link,
+		Title:   info.Title,
+		Updated: info.Updated.UTC().Format(time.RFC3339),
+		Author:  "lutakkols",
+		Links:   []atomLink{{Href: info.Link, Rel: "alternate"}},
+	}
+	if len(info.Self) > 0 {
+		feed.Links = append(feed.Links, atomLink{Href: info.Self, Rel: "self"})
+	}
+	for _, item := range items {
+		e := item.Event
+		entry := atomEntry{
+			ID:      EntryID(e.ID()),
+			Title:   e.Headline,
+			Updated: updated(item, info).UTC().Format(time.RFC3339),
+			Links:   []atomLink{{Href: e.EventLink, Rel: "alternate"}},
+		}
+		if len(e.SmallImageLink) > 0 {
+			entry.Links = append(entry.Links, atomLink{Href: e.SmallImageLink, Rel: "enclosure", Type: imageType(e.SmallImageLink)})
+		}
+		if c := htmlContent(item); len(c) > 0 {
+			entry.Content = &atomContent{Type: "html", Body: c}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// rssEnclosure is the image of the item, the length is required but it is not known without downloading the image
+// so it is 0 as recommended for unknown lengths
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int    `xml:"length,attr"`
+}
+
+// mediaContent is the image of the item as Media RSS for the readers which show it instead of the enclosure
+type mediaContent struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Medium string `xml:"medium,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+	Media       *mediaContent `xml:"http://search.yahoo.com/mrss/ content,omitempty"`
+}
+
+type rss struct {
+	XMLName       xml.Name  `xml:"rss"`
+	Version       string    `xml:"version,attr"`
+	Title         string    `xml:"channel>title"`
+	Link          string    `xml:"channel>link"`
+	Description   string    `xml:"channel>description"`
+	LastBuildDate string    `xml:"channel>lastBuildDate"`
+	Items         []rssItem `xml:"channel>item"`
+}
+
+func rssFeed(info FeedInfo, items []Item) rss {
+	feed := rss{
+		Version:       "2.0",
+		Title:         info.Title,
+		Link:          info.Link,
+		Description:   info.Title,
+		LastBuildDate: info.Updated.UTC().Format(time.RFC1123Z),
+	}
+	for _, item := range items {
+		e := item.Event
+		ri := rssItem{
+			Title:       e.Headline,
+			Link:        e.EventLink,
+			GUID:        rssGUID{Value: EntryID(e.ID())},
+			PubDate:     updated(item, info).UTC().Format(time.RFC1123Z),
+			Description: htmlContent(item),
+		}
+		if len(e.SmallImageLink) > 0 {
+			ri.Enclosure = &rssEnclosure{URL: e.SmallImageLink, Type: imageType(e.SmallImageLink)}
+			ri.Media = &mediaContent{URL: e.SmallImageLink, Type: imageType(e.SmallImageLink), Medium: "image"}
+		}
+		feed.Items = append(feed.Items, ri)
+	}
+	return feed
+}
+
+// updated is when the event was last updated, the time of the feed when it is not known
+func updated(item Item, info FeedInfo) time.Time {
+	if !item.Event.UpdatedAt.IsZero() {
+		return item.Event.UpdatedAt
+	}
+	return info.Updated
+}
+
+// htmlContent has the date, bullet points and the description of the event as html
+func htmlContent(item Item) string {
+	var sb strings.Builder
+	e := item.Event
+	if len(e.Date) > 0 {
+		sb.WriteString("<p>" + html.EscapeString(strings.TrimSpace(e.Weekday+" "+e.Date)) + "</p>")
+	}
+	if len(e.BulletPoints) > 0 {
+		sb.WriteString("<ul>")
+		for _, bp := range e.BulletPoints {
+			sb.WriteString("<li>" + html.EscapeString(bp) + "</li>")
+		}
+		sb.WriteString("</ul>")
+	}
+	if item.Details != nil {
+		for _, d := range item.Details.Description {
+			sb.WriteString("<p>" + html.EscapeString(d) + "</p>")
+		}
+	}
+	return sb.String()
+}
+
+// imageType guesses the media type of the image from its extension
+func imageType(link string) string {
+	if t := mime.TypeByExtension(path.Ext(link)); strings.HasPrefix(t, "image/") {
+		return t
+	}
+	return "image/jpeg"
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+var feedInfo = FeedInfo{
+	Title:   "Lutakko gigs",
+	Link:    "https://www.jelmu.net",
+	Self:    "http://localhost:8080/events.atom",
+	Updated: stamp,
+}
+
+func TestWriteAtom(t *testing.T) {
+	items := testItems()
+	items[0].Event.SmallImageLink = "https://www.jelmu.net/band-one.png"
+	items[0].Event.UpdatedAt = time.Date(2024, 9, 30, 10, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := WriteFeed(&buf, Atom, feedInfo, items); err != nil {
+		t.Fatalf("err writing feed: %s", err)
+	}
+
+	var feed atom
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatalf("err parsing feed: %s\n%s", err, buf.String())
+	}
+	if len(feed.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(feed.Entries))
+	}
+	first := feed.Entries[0]
+	if first.ID != "urn:lutakkols:event:band-one-18-10-2024" || first.Updated != "2024-09-30T10:00:00Z" {
+		t.Errorf("unexpected entry: %+v", first)
+	}
+	if len(first.Links) != 2 || first.Links[1].Rel != "enclosure" || first.Links[1].Type != "image/png" {
+		t.Errorf("expected the image as an enclosure: %+v", first.Links)
+	}
+	if first.Content == nil || !strings.Contains(first.Content.Body, "<li>Liput 25€</li>") ||
+		!strings.Contains(first.Content.Body, "<p>Long description") {
+		t.Errorf("expected the bullet points and description as content: %+v", first.Content)
+	}
+	if feed.Entries[1].Updated != "2024-10-01T12:00:00Z" {
+		t.Errorf("expected the time of the feed for an event without update time, got %s", feed.Entries[1].Updated)
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	items := testItems()
+	items[0].Event.SmallImageLink = "https://www.jelmu.net/band-one.jpg"
+
+	var buf bytes.Buffer
+	if err := WriteFeed(&buf, RSS, feedInfo, items); err != nil {
+		t.Fatalf("err writing feed: %s", err)
+	}
+
+	var feed rss
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatalf("err parsing feed: %s\n%s", err, buf.String())
+	}
+	if feed.Version != "2.0" || len(feed.Items) != 3 {
+		t.Fatalf("unexpected feed: %+v", feed)
+	}
+	first := feed.Items[0]
+	if first.GUID.Value != EntryID("band-one-18-10-2024") || first.GUID.IsPermaLink {
+		t.Errorf("unexpected guid: %+v", first.GUID)
+	}
+	if first.Media == nil || first.Media.Type != "image/jpeg" {
+		t.Errorf("expected the image as media content: %+v", first.Media)
+	}
+	if first.Enclosure == nil || first.Enclosure.URL != first.Media.URL || first.Enclosure.Type != "image/jpeg" {
+		t.Errorf("expected the image as an enclosure: %+v", first.Enclosure)
+	}
+	if !strings.Contains(buf.String(), `length="0"`) {
+		t.Errorf("expected the enclosure to have the unknown length:\n%s", buf.String())
+	}
+	if first.PubDate != "Tue, 01 Oct 2024 12:00:00 +0000" {
+		t.Errorf("unexpected pubDate %s", first.PubDate)
+	}
+}
+
+func TestParseFeedFormat(t *testing.T) {
+	if f, err := ParseFeedFormat("RSS"); err != nil || f != RSS {
+		t.Errorf("expected rss, got %s %v", f, err)
+	}
+	if _, err := ParseFeedFormat("json"); err == nil {
+		t.Errorf("expected err for unknown format")
+	}
+}
@@ -0,0 +1,25 @@
+package export
+
+import (
+	"io"
+	"os"
+)
+
+// WriteFile writes into a temporary file next to the path and renames it over the path once the write has
+// succeeded, so that a failed export does not replace an earlier one
+func WriteFile(path string, write func(w io.Writer) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,194 @@
+// Package export renders the events into formats used by other applications such as calendars and feed readers
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"github.com/johannessarpola/lutakkols/pkg/filter"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// CalendarName is shown by the calendar applications for the subscribed calendar
+	CalendarName = "Lutakko gigs"
+	prodID       = "-//lutakkols//lutakkols//EN"
+	uidDomain    = "lutakkols"
+	// lastSetLength is how long the last act is assumed to play when calculating the end of the event
+	lastSetLength = time.Hour
+	// maxLineOctets is the maximum length of a content line before it is folded
+	maxLineOctets = 75
+	icsTimeFormat = "20060102T150405Z"
+	icsDateFormat = "20060102"
+)
+
+// Item is an event with its details, Details is nil when they are not available
+type Item struct {
+	Event   models.Event
+	Details *models.EventDetails
+}
+
+// span is when the event takes place, all day events have only the days
+type span struct {
+	start  time.Time
+	end    *time.Time
+	allDay bool
+}
+
+// WriteICS writes the items as a RFC 5545 calendar, items without a date are left out as they can not be placed
+// into a calendar. The UIDs are derived from the event IDs so that updated events replace the earlier ones.
+func WriteICS(w io.Writer, items []Item, stamp time.Time) error {
+	bw := bufio.NewWriter(w)
+	ics := icsWriter{w: bw}
+	ics.line("BEGIN", "VCALENDAR")
+	ics.line("VERSION", "2.0")
+	ics.line("PRODID", prodID)
+	ics.line("CALSCALE", "GREGORIAN")
+	ics.line("METHOD", "PUBLISH")
+	ics.line("X-WR-CALNAME", escapeText(CalendarName))
+	for _, item := range items {
+		s, ok := eventSpan(item)
+		if !ok {
+			logger.Log.Warnf("leaving event %s out of the calendar as it has no date", item.Event.ID())
+			continue
+		}
+		writeEvent(&ics, item, s, stamp)
+	}
+	ics.line("END", "VCALENDAR")
+	if ics.err != nil {
+		return ics.err
+	}
+	return bw.Flush()
+}
+
+// UID returns the calendar UID of the event
+func UID(e models.Event) string {
+	return fmt.Sprintf("%s@%s", e.ID(), uidDomain)
+}
+
+func writeEvent(ics *icsWriter, item Item, s span, stamp time.Time) {
+	e := item.Event
+	if !e.UpdatedAt.IsZero() {
+		stamp = e.UpdatedAt
+	}
+	ics.line("BEGIN", "VEVENT")
+	ics.line("UID", UID(e))
+	ics.line("DTSTAMP", stamp.UTC().Format(icsTimeFormat))
+	if s.allDay {
+		ics.line("DTSTART;VALUE=DATE", s.start.Format(icsDateFormat))
+		ics.line("DTEND;VALUE=DATE", s.start.AddDate(0, 0, 1).Format(icsDateFormat))
+	} else {
+		ics.line("DTSTART", s.start.UTC().Format(icsTimeFormat))
+		if s.end != nil {
+			ics.line("DTEND", s.end.UTC().Format(icsTimeFormat))
+		}
+	}
+	ics.line("SUMMARY", escapeText(e.Headline))
+	if len(e.Venue) > 0 {
+		ics.line("LOCATION", escapeText(e.Venue))
+	}
+	if len(e.EventLink) > 0 {
+		ics.line("URL", e.EventLink)
+	}
+	if d := description(item); len(d) > 0 {
+		ics.line("DESCRIPTION", escapeText(d))
+	}
+	ics.line("END", "VEVENT")
+}
+
+// eventSpan uses the parsed play times when available, otherwise the event is all day long
+func eventSpan(item Item) (span, bool) {
+	day, ok := filter.StartDay(item.Event)
+	if !ok {
+		return span{}, false
+	}
+	if item.Details == nil {
+		return span{start: day, allDay: true}, true
+	}
+
+	doors, sets := item.Details.DoorsOpen, item.Details.SetTimes
+	if doors == nil && len(sets) == 0 {
+		doors, sets, _ = dates.Schedule(day, item.Details.PlayTimes)
+	}
+
+	var s span
+	switch {
+	case doors != nil:
+		s.start = *doors
+	case len(sets) > 0:
+		s.start = sets[0].StartsAt
+	default:
+		return span{start: day, allDay: true}, true
+	}
+	if len(sets) > 0 {
+		end := sets[len(sets)-1].StartsAt.Add(lastSetLength)
+		s.end = &end
+	}
+	return s, true
+}
+
+// description has the bullet points, the description of the details and the links to the event and tickets
+func description(item Item) string {
+	var parts []string
+	if len(item.Event.BulletPoints) > 0 {
+		parts = append(parts, strings.Join(item.Event.BulletPoints, "\n"))
+	}
+	if item.Details != nil {
+		if len(item.Details.PlayTimes) > 0 {
+			parts = append(parts, strings.Join(item.Details.PlayTimes, "\n"))
+		}
+		parts = append(parts, item.Details.Description...)
+	}
+	if len(item.Event.StoreLink) > 0 {
+		parts = append(parts, "Tickets: "+item.Event.StoreLink)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// escapeText escapes a TEXT value as in RFC 5545 3.3.11
+func escapeText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// icsWriter writes content lines ending with CRLF and folded to 75 octets, the first error is kept
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (i *icsWriter) line(name string, value string) {
+	if i.err != nil {
+		return
+	}
+	_, i.err = io.WriteString(i.w, fold(name+":"+value))
+}
+
+// fold splits the line into 75 octet lines without breaking multibyte characters
+func fold(line string) string {
+	var sb strings.Builder
+	limit := maxLineOctets
+	n := 0
+	for _, r := range line {
+		size := len(string(r))
+		if n+size > limit {
+			sb.WriteString("\r\n ")
+			// the leading space counts towards the length of the continuation line
+			limit = maxLineOctets - 1
+			n = 0
+		}
+		sb.WriteRune(r)
+		n += size
+	}
+	sb.WriteString("\r\n")
+	return sb.String()
+}
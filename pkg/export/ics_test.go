@@ -0,0 +1,98 @@
+package export
+
+import (
+	"bytes"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"strings"
+	"testing"
+	"time"
+)
+
+var stamp = time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func testItems() []Item {
+	startsAt := time.Date(2024, 10, 18, 0, 0, 0, 0, dates.Location)
+	return []Item{
+		{
+			Event: models.Event{
+				Id:           "band-one-18-10-2024",
+				Headline:     "Band One, Opener; Special",
+				EventLink:    "https://www.jelmu.net/tapahtuma/band-one-18-10-2024/",
+				Date:         "18.10.",
+				StartsAt:     &startsAt,
+				StoreLink:    "https://www.jelmu.net/kauppa/band-one/",
+				BulletPoints: []string{"Liput 25€"},
+				Venue:        "Lutakko",
+			},
+			Details: &models.EventDetails{
+				EventID:     "band-one-18-10-2024",
+				Description: []string{strings.Repeat("Long description ", 10)},
+				PlayTimes:   []string{"Ovet klo 19.00", "Opener 20:00", "Band One 21:30"},
+			},
+		},
+		{
+			Event: models.Event{Id: "all-day", Headline: "Flea market", Date: "2024-11-02"},
+		},
+		{
+			Event: models.Event{Id: "undated", Headline: "Someday", Date: "TBA"},
+		},
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, testItems(), stamp); err != nil {
+		t.Fatalf("err writing ics: %s", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:band-one-18-10-2024@lutakkols\r\n",
+		"DTSTAMP:20241001T120000Z\r\n",
+		"DTSTART:20241018T160000Z\r\n",
+		"DTEND:20241018T193000Z\r\n",
+		"SUMMARY:Band One\\, Opener\\; Special\r\n",
+		"LOCATION:Lutakko\r\n",
+		"URL:https://www.jelmu.net/tapahtuma/band-one-18-10-2024/\r\n",
+		"UID:all-day@lutakkols\r\n",
+		"DTSTART;VALUE=DATE:20241102\r\n",
+		"DTEND;VALUE=DATE:20241103\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "undated") {
+		t.Errorf("expected the event without a date to be left out")
+	}
+	if strings.Count(out, "BEGIN:VEVENT") != 2 {
+		t.Errorf("expected 2 events")
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, `DESCRIPTION:Liput 25€\n\nOvet klo 19.00\nOpener 20:00`) ||
+		!strings.Contains(unfolded, `\n\nTickets: https://www.jelmu.net/kauppa/band-one/`) {
+		t.Errorf("unexpected description in:\n%s", unfolded)
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(l) > maxLineOctets {
+			t.Errorf("line longer than %d octets: %q", maxLineOctets, l)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	line := strings.Repeat("ä", 50)
+	folded := fold(line)
+	if strings.ReplaceAll(folded, "\r\n ", "") != line+"\r\n" {
+		t.Errorf("folding changed the content: %q", folded)
+	}
+	for _, l := range strings.Split(folded, "\r\n") {
+		if len(l) > maxLineOctets {
+			t.Errorf("line longer than %d octets: %q", maxLineOctets, l)
+		}
+	}
+}
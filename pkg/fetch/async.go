@@ -2,6 +2,7 @@ package fetch
 
 import (
 	"context"
+	"fmt"
 	"github.com/gocolly/colly/v2"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
 	"github.com/johannessarpola/lutakkols/pkg/fetch/selectors"
@@ -15,16 +16,16 @@ type asyncSource struct{}
 var Async asyncSource
 
 // Events loads the events and has a rate limiting functionality for the output channel
-func (a asyncSource) Events(url string, max int, ctx context.Context) chan models.Event {
+func (a asyncSource) Events(ctx context.Context, url string, max int) chan models.Event {
 	out := make(chan models.Event)
 
 	go func() {
 		defer close(out)
 		ord := 0
-		c := newCollector()
+		c := newCollector(ctx)
 		var events []models.Event
 
-		c.OnHTML(selectors.Events, func(e *colly.HTMLElement) {
+		c.OnHTML(selectors.Current().Events, func(e *colly.HTMLElement) {
 
 			if len(events) == max && max != 0 {
 				return
@@ -37,9 +38,17 @@ func (a asyncSource) Events(url string, max int, ctx context.Context) chan model
 
 		})
 
-		e := c.Visit(url)
+		e := visit(ctx, c, url)
+		if e == nil && len(events) == 0 {
+			e = parseError(url, fmt.Errorf("no events matched %q", selectors.Current().Events))
+		}
 		if e != nil {
-			panic(e)
+			if ctx.Err() != nil {
+				logger.Log.Warnf("Context cancelled while fetching events")
+				return
+			}
+			logger.Log.Errorf("Could not fetch events: %v", e)
+			return
 		}
 		logger.Log.Debugf("Forwarding %d events into channel", len(events))
 
@@ -60,7 +69,7 @@ func (a asyncSource) Events(url string, max int, ctx context.Context) chan model
 
 // Images gets a channel of ascii images for event details, respecting context
 // pointers are used so that there's no copying by value
-func (a asyncSource) Images(eds <-chan models.EventDetails, ctx context.Context) <-chan pipes.Result[models.EventAscii] {
+func (a asyncSource) Images(ctx context.Context, eds <-chan models.EventDetails) <-chan pipes.Result[models.EventAscii] {
 	out := make(chan pipes.Result[models.EventAscii])
 
 	go func() {
@@ -73,7 +82,7 @@ func (a asyncSource) Images(eds <-chan models.EventDetails, ctx context.Context)
 				if !ok {
 					return
 				}
-				v, err := Sync.EventImage(ed.ImageURL(), ed.ID())
+				v, err := Sync.EventImage(ctx, ed.ImageURL(), ed.ID())
 
 				var result pipes.Result[models.EventAscii]
 				if err != nil {
@@ -102,7 +111,10 @@ func (a asyncSource) Details(ctx context.Context, eps <-chan models.Event) <-cha
 				if !ok {
 					return
 				}
-				v, err := Sync.EventDetails(ep.EventURL(), ep.ID())
+				v, err := Sync.EventDetails(ctx, ep.EventURL(), ep.ID())
+				if err == nil && v.DoorsOpen == nil && v.SetTimes == nil && ep.StartsAt != nil {
+					ApplySchedule(&v, *ep.StartsAt)
+				}
 
 				var result pipes.Result[models.EventDetails]
 				if err != nil {
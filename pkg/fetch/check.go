@@ -0,0 +1,117 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/selectors"
+)
+
+// SelectorCheck is the result of running a single selector of the profile against a page
+type SelectorCheck struct {
+	Name     string
+	Selector string
+	Page     string
+	Matches  int
+	// Optional selectors are allowed to match nothing, for example when nothing is sold out
+	Optional bool
+}
+
+// Missed tells if the selector should have matched something but did not
+func (c SelectorCheck) Missed() bool {
+	return c.Matches == 0 && !c.Optional
+}
+
+type scopedSelector struct {
+	name     string
+	scope    string
+	selector string
+	optional bool
+}
+
+func listingSelectors(p *selectors.Profile) []scopedSelector {
+	return []scopedSelector{
+		{name: "events", selector: p.Events},
+		{name: "event_link", scope: p.Events, selector: p.EventLink},
+		{name: "event_store_link", scope: p.Events, selector: p.EventStoreLink, optional: true},
+		{name: "event_small_image", scope: p.Events, selector: p.EventSmallImage},
+		{name: "event_week_day", scope: p.Events, selector: p.EventWeekDay},
+		{name: "event_date", scope: p.Events, selector: p.EventDate},
+		{name: "event_headliners", scope: p.Events, selector: p.EventHeadliners},
+		{name: "event_backup_headliners", scope: p.Events, selector: p.EventBackupHeadliners},
+		{name: "bullet_points", scope: p.Events, selector: p.BulletPoints, optional: true},
+		{name: "out_of_stock", scope: p.Events, selector: p.OutOfStock, optional: true},
+	}
+}
+
+func eventSelectors(p *selectors.Profile) []scopedSelector {
+	return []scopedSelector{
+		{name: "event_summary", selector: p.EventSummary},
+		{name: "paragraph_no_class", scope: p.EventSummary, selector: p.ParagraphNoClass},
+		{name: "image_link", scope: p.EventSummary, selector: p.ImageLink},
+		{name: "event_product_info_mobile", scope: p.EventSummary, selector: p.EventProductInfoMobile, optional: true},
+		{name: "event_product_info", selector: p.EventProductInfo},
+		{name: "event_product_info_parts", scope: p.EventProductInfo, selector: p.EventProductInfoParts},
+		{name: "event_play_times", scope: p.EventProductInfo, selector: p.EventPlayTimes, optional: true},
+		{name: "event_tickets", selector: p.EventTickets, optional: true},
+		{name: "ticket_prices", scope: p.EventTickets, selector: p.TicketPrices, optional: true},
+		{name: "ticket_description", scope: p.TicketPrices, selector: p.TicketDescription, optional: true},
+		{name: "ticket_price", scope: p.TicketPrices, selector: p.TicketPrice, optional: true},
+		{name: "door_price", selector: p.DoorPrice, optional: true},
+	}
+}
+
+func loadDocument(ctx context.Context, url string) (*goquery.Document, error) {
+	body, err := withRetry(ctx, func() ([]byte, error) {
+		return get(ctx, url)
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, parseError(url, err)
+	}
+	return doc, nil
+}
+
+func runChecks(doc *goquery.Document, page string, checks []scopedSelector) []SelectorCheck {
+	var rs []SelectorCheck
+	for _, c := range checks {
+		matches := doc.Selection
+		if len(c.scope) > 0 {
+			matches = matches.Find(c.scope)
+		}
+		rs = append(rs, SelectorCheck{
+			Name:     c.name,
+			Selector: c.selector,
+			Page:     page,
+			Matches:  matches.Find(c.selector).Length(),
+			Optional: c.optional,
+		})
+	}
+	return rs
+}
+
+// CheckSelectors runs the profile against the listing page and an event page, if the eventURL is empty the first
+// event link found from the listing is used
+func CheckSelectors(ctx context.Context, p *selectors.Profile, listingURL string, eventURL string) ([]SelectorCheck, error) {
+	listing, err := loadDocument(ctx, listingURL)
+	if err != nil {
+		return nil, err
+	}
+	rs := runChecks(listing, listingURL, listingSelectors(p))
+
+	if len(eventURL) == 0 {
+		eventURL, _ = listing.Find(p.Events).First().Find(p.EventLink).Attr("href")
+	}
+	if len(eventURL) == 0 {
+		return rs, nil
+	}
+
+	event, err := loadDocument(ctx, eventURL)
+	if err != nil {
+		return rs, err
+	}
+	return append(rs, runChecks(event, eventURL, eventSelectors(p))...), nil
+}
@@ -1,12 +1,31 @@
 package fetch
 
 import (
+	"context"
 	"github.com/gocolly/colly/v2"
 	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"net/http"
+	"time"
 )
 
 const UserAgent = "Lutakko CLI (beta)"
 
+// transport is used for all the requests, it can be replaced for example to record the responses
+var transport http.RoundTripper = http.DefaultTransport
+
+// now is the clock for the timestamps of fetched models, replaced in tests
+var now = time.Now
+
+// SetTransport replaces the transport used for the requests
+func SetTransport(rt http.RoundTripper) {
+	transport = rt
+}
+
+// httpClient returns the client to use for requests outside of the collector
+func httpClient() *http.Client {
+	return &http.Client{Transport: transport}
+}
+
 // collectorOptions returns the options used with customized collector
 func collectorOptions() []colly.CollectorOption {
 	return []colly.CollectorOption{
@@ -14,10 +33,26 @@ func collectorOptions() []colly.CollectorOption {
 	}
 }
 
-// newCollector setups the customized collector used within the application
-func newCollector() *colly.Collector {
+// contextTransport binds the requests into a context as colly does not support contexts
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// newCollector setups the customized collector used within the application, requests are cancelled with the ctx
+func newCollector(ctx context.Context) *colly.Collector {
 	c := colly.NewCollector(collectorOptions()...)
+	c.WithTransport(contextTransport{ctx: ctx, base: transport})
 	c.OnRequest(func(request *colly.Request) {
+		if ctx.Err() != nil {
+			logger.Log.Debugf("not visiting %s as context is done", request.URL.String())
+			request.Abort()
+			return
+		}
 		logger.Log.Debugf("visiting %s", request.URL.String())
 	})
 	c.OnResponse(func(r *colly.Response) {
@@ -1,12 +1,140 @@
 package fetch
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
 
-type FailedFetch struct {
-	url string
-	err error
+// Kind classifies why a fetch failed
+type Kind int
+
+const (
+	_ Kind = iota
+	// KindNetwork is a failure to reach the source
+	KindNetwork
+	// KindStatus is an unsuccessful HTTP status from the source
+	KindStatus
+	// KindParse is a page which did not match the selectors, usually the markup of the source has changed
+	KindParse
+	// KindImage is an image which could not be decoded
+	KindImage
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNetwork:
+		return "network"
+	case KindStatus:
+		return "http status"
+	case KindParse:
+		return "parse"
+	case KindImage:
+		return "image"
+	default:
+		return "unknown"
+	}
+}
+
+// FetchError is a failed fetch, RetryAfter is set when the source asked to wait before retrying
+type FetchError struct {
+	Kind       Kind
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (f *FetchError) Error() string {
+	if f.Kind == KindStatus {
+		return fmt.Sprintf("failed to fetch url: %s with status %d", f.URL, f.StatusCode)
+	}
+	return fmt.Sprintf("failed to fetch url: %s with %s error: %v", f.URL, f.Kind, f.Err)
+}
+
+func (f *FetchError) Unwrap() error {
+	return f.Err
+}
+
+// Transient tells if the failure is temporary so that retrying later can succeed
+func (f *FetchError) Transient() bool {
+	switch f.Kind {
+	case KindNetwork:
+		return true
+	case KindStatus:
+		return f.StatusCode == http.StatusTooManyRequests ||
+			f.StatusCode == http.StatusRequestTimeout ||
+			f.StatusCode >= http.StatusInternalServerError
+	default:
+		return false
+	}
+}
+
+// IsTransient tells if the error is a temporary failure of the source instead of for example a broken scraper
+func IsTransient(err error) bool {
+	var fe *FetchError
+	return errors.As(err, &fe) && fe.Transient()
+}
+
+// KindOf returns the kind of the fetch error, 0 if the error is not from a fetch
+func KindOf(err error) Kind {
+	var fe *FetchError
+	if errors.As(err, &fe) {
+		return fe.Kind
+	}
+	return 0
+}
+
+// Diagnose describes the error as either an outage of the source or a broken scraper
+func Diagnose(err error) string {
+	switch {
+	case IsTransient(err):
+		return "the source is unavailable, try again later"
+	case KindOf(err) == KindParse:
+		return "the page did not match the selectors, the scraper may be broken"
+	case KindOf(err) == KindStatus:
+		return "the source refused the request"
+	case KindOf(err) == KindImage:
+		return "the image could not be decoded"
+	default:
+		return "unknown failure"
+	}
+}
+
+func networkError(url string, err error) *FetchError {
+	return &FetchError{Kind: KindNetwork, URL: url, Err: err}
+}
+
+func statusError(url string, statusCode int, header http.Header) *FetchError {
+	return &FetchError{
+		Kind:       KindStatus,
+		URL:        url,
+		StatusCode: statusCode,
+		RetryAfter: retryAfter(header.Get("Retry-After"), now()),
+		Err:        errors.New(http.StatusText(statusCode)),
+	}
+}
+
+func parseError(url string, err error) *FetchError {
+	return &FetchError{Kind: KindParse, URL: url, Err: err}
+}
+
+func imageError(url string, err error) *FetchError {
+	return &FetchError{Kind: KindImage, URL: url, Err: err}
 }
 
-func (f FailedFetch) Error() string {
-	return fmt.Sprintf("failed to fetch url: %s with %s", f.url, f.err)
+// retryAfter parses the Retry-After header which is either seconds or a http date
+func retryAfter(value string, ref time.Time) time.Duration {
+	if len(value) == 0 {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(ref), 0)
+	}
+	return 0
 }
@@ -3,19 +3,23 @@ package fetch
 import (
 	"github.com/gocolly/colly/v2"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
 	"github.com/johannessarpola/lutakkols/pkg/fetch/selectors"
 	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/prices"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 func extractInStock(e *colly.HTMLElement) bool {
-	s := strings.TrimSpace(e.ChildText(selectors.OutOfStock))
+	s := strings.TrimSpace(e.ChildText(selectors.Current().OutOfStock))
 	return len(s) == 0
 }
 
 func extractImageLink(e *colly.HTMLElement) string {
-	imageLink := e.ChildAttr(selectors.ImageLink, "src")
+	imageLink := e.ChildAttr(selectors.Current().ImageLink, "src")
 	return imageLink
 }
 
@@ -29,11 +33,12 @@ func stripHTML(input string) string {
 }
 
 func extractSummary(e *colly.HTMLElement) []string {
+	sel := selectors.Current()
 	//	summary := strings.Join(e.ChildTexts(paragraphNoClassSelector), "\n")
 	var items []string
-	e.ForEach(selectors.ParagraphNoClass, func(i int, element *colly.HTMLElement) {
+	e.ForEach(sel.ParagraphNoClass, func(i int, element *colly.HTMLElement) {
 		// Drop paragraphs under product info mobile which is a sub div inside the .summary div
-		if element.DOM.ParentsFiltered(selectors.EventProductInfoMobile).Length() == 0 {
+		if element.DOM.ParentsFiltered(sel.EventProductInfoMobile).Length() == 0 {
 			htmlContent, _ := element.DOM.Html()
 
 			withLineBreaks := strings.ReplaceAll(htmlContent, "<br>", "\n")
@@ -50,7 +55,7 @@ func extractSummary(e *colly.HTMLElement) []string {
 
 func extractProdductInfo(e *colly.HTMLElement) map[string]string {
 	// parse types from product info for event info
-	cts := e.ChildTexts(selectors.EventProductInfoParts)
+	cts := e.ChildTexts(selectors.Current().EventProductInfoParts)
 	cnt := len(cts)
 	productInfo := make(map[string]string, cnt)
 
@@ -68,20 +73,22 @@ func extractProdductInfo(e *colly.HTMLElement) map[string]string {
 
 func extractPlayTimes(e *colly.HTMLElement) []string {
 	var playtimes []string
-	e.ForEach(selectors.EventPlayTimes, func(i int, element *colly.HTMLElement) {
+	e.ForEach(selectors.Current().EventPlayTimes, func(i int, element *colly.HTMLElement) {
 		playtimes = append(playtimes, element.Text)
 	})
 	return playtimes
 }
 
 func extractTicketPrices(e *colly.HTMLElement) models.EventTickets {
+	sel := selectors.Current()
 	var tickets []models.Ticket
-	e.ForEach(selectors.TicketPrices, func(i int, element *colly.HTMLElement) {
-		description := element.ChildText("h3")
-		price := element.ChildText("bdi")
+	e.ForEach(sel.TicketPrices, func(i int, element *colly.HTMLElement) {
+		description := element.ChildText(sel.TicketDescription)
+		price := element.ChildText(sel.TicketPrice)
 		tickets = append(tickets, models.Ticket{
 			Description: description,
 			Price:       price,
+			ParsedPrice: parsePrice(price),
 		})
 	})
 
@@ -90,14 +97,13 @@ func extractTicketPrices(e *colly.HTMLElement) models.EventTickets {
 	}
 }
 
+var doorPriceRe = regexp.MustCompile(`(?i)hinta ovelta:?`)
+
 func extractDoorPrice(e *colly.HTMLElement) models.DoorPrice {
-	magicString := "hinta ovelta"
-	cs := e.ChildTexts(selectors.ParagraphNoClass)
+	cs := e.ChildTexts(selectors.Current().ParagraphNoClass)
 	for _, c := range cs {
-		l := strings.ToLower(c)
-		r := strings.Contains(l, magicString)
-		if r {
-			dp := strings.TrimSpace(strings.Replace(l, magicString, "", 1))
+		if doorPriceRe.MatchString(c) {
+			dp := strings.TrimSpace(doorPriceRe.ReplaceAllString(c, ""))
 			return dp
 		}
 	}
@@ -105,9 +111,22 @@ func extractDoorPrice(e *colly.HTMLElement) models.DoorPrice {
 	return ""
 }
 
+// parsePrice parses the price text and warns if it could not be parsed, nil is returned for empty or invalid text
+func parsePrice(text string) *models.Price {
+	if len(strings.TrimSpace(text)) == 0 {
+		return nil
+	}
+	p, err := prices.Parse(text)
+	if err != nil {
+		logger.Log.Warnf("keeping original price text: %s", err.Error())
+		return nil
+	}
+	return &p
+}
+
 func extractBulletPoints(e *colly.HTMLElement) []string {
 	var points []string
-	spans := e.ChildTexts(selectors.BulletPoints)
+	spans := e.ChildTexts(selectors.Current().BulletPoints)
 	for _, p := range spans {
 		points = append(points, strings.TrimSpace(p))
 	}
@@ -115,12 +134,13 @@ func extractBulletPoints(e *colly.HTMLElement) []string {
 }
 
 func extractEvent(e *colly.HTMLElement) models.Event {
+	sel := selectors.Current()
 
-	eventLink := e.ChildAttr(selectors.EventLink, "href")
-	storeLink := e.ChildAttr(selectors.EventStoreLink, "href")
-	imageLink := e.ChildAttr(selectors.EventSmallImage, "src")
-	weekDay := strings.TrimSpace(e.ChildText(selectors.EventWeekDay))
-	date := strings.TrimSpace(e.ChildText(selectors.EventDate))
+	eventLink := e.ChildAttr(sel.EventLink, "href")
+	storeLink := e.ChildAttr(sel.EventStoreLink, "href")
+	imageLink := e.ChildAttr(sel.EventSmallImage, "src")
+	weekDay := strings.TrimSpace(e.ChildText(sel.EventWeekDay))
+	date := strings.TrimSpace(e.ChildText(sel.EventDate))
 	headline := extractHeadline(e)
 	inStock := extractInStock(e)
 
@@ -133,24 +153,21 @@ func extractEvent(e *colly.HTMLElement) models.Event {
 	evt.BulletPoints = extractBulletPoints(e)
 	evt.Headline = cleanupHeadline(headline)
 	evt.InStock = inStock
+	evt.StartsAt = extractStartsAt(evt, now())
 
-	id, err := createEventID(evt)
-	if err != nil {
-		// todo fall back
-		logger.Log.Warnf("could not resolve key for event %s", err.Error())
-	}
-	evt.Id = id
+	evt.Id = createEventID(evt)
 	return evt
 }
 
 func extractHeadline(e *colly.HTMLElement) string {
+	sel := selectors.Current()
 	var headliners []string
-	e.ForEach(selectors.EventHeadliners, func(i int, element *colly.HTMLElement) {
+	e.ForEach(sel.EventHeadliners, func(i int, element *colly.HTMLElement) {
 		headliners = append(headliners, strings.TrimSpace(element.Text))
 	})
 
 	if len(headliners) == 0 {
-		return e.ChildText(selectors.EventBackupHeadliners)
+		return e.ChildText(sel.EventBackupHeadliners)
 	} else {
 		return strings.Join(headliners, " | ")
 	}
@@ -161,3 +178,47 @@ func cleanupHeadline(input string) string {
 	output := re.ReplaceAllString(input, " ")
 	return output
 }
+
+func extractStartsAt(evt models.Event, ref time.Time) *time.Time {
+	t, err := dates.ParseDay(evt.Date, ref)
+	if err != nil {
+		logger.Log.Warnf("could not resolve start for event %s: %s", evt.Headline, err.Error())
+		return nil
+	}
+	return &t
+}
+
+// eventDayKey is the product info entry with the date of the event
+const eventDayKey = "Päivämäärä"
+
+// extractEventDay parses the date of the event from the product info, when the date entry is missing the other
+// entries are searched for a value with a date
+func extractEventDay(productInfo map[string]string, ref time.Time) (time.Time, bool) {
+	if v, ok := productInfo[eventDayKey]; ok {
+		t, err := dates.ParseDay(v, ref)
+		return t, err == nil
+	}
+
+	keys := make([]string, 0, len(productInfo))
+	for k := range productInfo {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if t, err := dates.ParseDay(productInfo[k], ref); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ApplySchedule parses the play times of the details on the given day, unparseable lines are logged
+func ApplySchedule(ed *models.EventDetails, day time.Time) {
+	doors, sets, unparsed := dates.Schedule(day, ed.PlayTimes)
+	ed.DoorsOpen = doors
+	ed.SetTimes = sets
+	for _, u := range unparsed {
+		logger.Log.Warnf("could not parse play time '%s' for event %s", u, ed.EventID)
+	}
+}
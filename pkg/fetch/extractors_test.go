@@ -0,0 +1,46 @@
+package fetch
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"testing"
+	"time"
+)
+
+func TestExtractEventDay(t *testing.T) {
+	ref := time.Date(2024, 10, 1, 12, 0, 0, 0, dates.Location)
+
+	tests := []struct {
+		name        string
+		productInfo map[string]string
+		want        time.Time
+		ok          bool
+	}{
+		{
+			name: "date entry",
+			productInfo: map[string]string{
+				"Ikäraja":         "K18",
+				"Liput myynnissä": "1.3.",
+				eventDayKey:       "pe 18.10.2024",
+			},
+			want: time.Date(2024, 10, 18, 0, 0, 0, 0, dates.Location),
+			ok:   true,
+		},
+		{
+			name:        "unparseable date entry",
+			productInfo: map[string]string{"Liput myynnissä": "1.3.", eventDayKey: "tba"},
+		},
+		{
+			name:        "no date entry",
+			productInfo: map[string]string{"Ajankohta": "la 19.10."},
+			want:        time.Date(2024, 10, 19, 0, 0, 0, 0, dates.Location),
+			ok:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := extractEventDay(tt.productInfo, ref)
+		if ok != tt.ok || (ok && !got.Equal(tt.want)) {
+			t.Errorf("%s: got %s %t, want %s %t", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -4,6 +4,8 @@ package fetch
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"github.com/gocolly/colly/v2"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
 	"github.com/johannessarpola/lutakkols/pkg/fetch/selectors"
@@ -11,7 +13,6 @@ import (
 	"image"
 	"io"
 	"net/http"
-	"time"
 )
 
 type syncSource struct{}
@@ -20,107 +21,181 @@ type syncSource struct{}
 var Sync syncSource
 
 // EventImage fetches normal image file and turns it into an ascii art
-func (_ syncSource) EventImage(url string, eventID string) (models.EventAscii, error) {
+func (_ syncSource) EventImage(ctx context.Context, url string, eventID string) (models.EventAscii, error) {
 	var rs models.EventAscii
-	img, err := downloadImage(url)
+	img, err := withRetry(ctx, func() (*image.Image, error) {
+		return downloadImage(ctx, url)
+	})
 	if err != nil {
-		return rs, FailedFetch{err: err, url: url}
+		return rs, err
 	}
 
 	converter := convert.NewImageConverter()
 	rs.Ascii = converter.Image2ASCIIString(*img, defaultConvertorOptions())
 	rs.EventID = eventID
-	rs.UpdatedAt = time.Now()
+	rs.UpdatedAt = now()
 	return rs, nil
 }
 
 func handleEvent(ord int, e *colly.HTMLElement) (models.Event, error) {
 	evt := extractEvent(e)
-	evt.UpdatedAt = time.Now()
+	evt.UpdatedAt = now()
 	evt.Order = ord
 	return evt, nil
 }
 
-// Events fetches the events from the source
-func (_ syncSource) Events(url string) ([]models.Event, error) {
-	c := newCollector()
+// Events fetches the events from the source, a listing without any events is a parse error
+func (_ syncSource) Events(ctx context.Context, url string) ([]models.Event, error) {
+	return withRetry(ctx, func() ([]models.Event, error) {
+		return events(ctx, url)
+	})
+}
+
+func events(ctx context.Context, url string) ([]models.Event, error) {
+	c := newCollector(ctx)
 	var events []models.Event
 	ord := 0
 
-	c.OnHTML(selectors.Events, func(e *colly.HTMLElement) {
+	c.OnHTML(selectors.Current().Events, func(e *colly.HTMLElement) {
 		evt := extractEvent(e)
-		evt.UpdatedAt = time.Now()
+		evt.UpdatedAt = now()
 		evt.Order = ord
 		ord += 1
 		events = append(events, evt)
 	})
 
-	err := c.Visit(url)
+	err := visit(ctx, c, url)
 	if err != nil {
-		return nil, FailedFetch{err: err, url: url}
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, parseError(url, fmt.Errorf("no events matched %q", selectors.Current().Events))
 	}
 
 	return events, nil
 }
 
-// EventDetails fetches the eventDetails for eventUrl from source
-func (_ syncSource) EventDetails(url string, eventId string) (models.EventDetails, error) {
-	c := newCollector()
+// EventDetails fetches the eventDetails for eventUrl from source, a page without summary or product info is a parse error
+func (_ syncSource) EventDetails(ctx context.Context, url string, eventId string) (models.EventDetails, error) {
+	return withRetry(ctx, func() (models.EventDetails, error) {
+		return eventDetails(ctx, url, eventId)
+	})
+}
+
+func eventDetails(ctx context.Context, url string, eventId string) (models.EventDetails, error) {
+	sel := selectors.Current()
+	c := newCollector(ctx)
 	ed := models.EventDetails{}
 	ed.EventID = eventId
-	ed.UpdatedAt = time.Now()
+	ed.UpdatedAt = now()
+
+	matched := false
 
 	// extract the product info for event
-	c.OnHTML(selectors.EventProductInfo, func(e *colly.HTMLElement) {
+	c.OnHTML(sel.EventProductInfo, func(e *colly.HTMLElement) {
+		matched = true
 		ed.ProductInfo = extractProdductInfo(e)
 		ed.PlayTimes = extractPlayTimes(e)
 	})
 
 	// extract product summary
-	c.OnHTML(selectors.EventSummary, func(e *colly.HTMLElement) {
+	c.OnHTML(sel.EventSummary, func(e *colly.HTMLElement) {
+		matched = true
 		ed.Description = extractSummary(e)
 		ed.ImageLink = extractImageLink(e)
 	})
 
 	// extract tickets
-	c.OnHTML(selectors.EventTickets, func(e *colly.HTMLElement) {
+	c.OnHTML(sel.EventTickets, func(e *colly.HTMLElement) {
 		ed.Tickets = extractTicketPrices(e)
 	})
 
 	// extract door price
-	c.OnHTML(selectors.DoorPrice, func(e *colly.HTMLElement) {
+	c.OnHTML(sel.DoorPrice, func(e *colly.HTMLElement) {
 		ed.DoorPrice = extractDoorPrice(e)
+		ed.ParsedDoorPrice = parsePrice(ed.DoorPrice)
 	})
 
-	err := c.Visit(url)
+	err := visit(ctx, c, url)
 	if err != nil {
-		return ed, FailedFetch{err: err, url: url}
+		return ed, err
+	}
+	if !matched {
+		return ed, parseError(url, fmt.Errorf("neither %q nor %q matched", sel.EventSummary, sel.EventProductInfo))
+	}
+
+	if day, ok := extractEventDay(ed.ProductInfo, ed.UpdatedAt); ok {
+		ApplySchedule(&ed, day)
 	}
 	return ed, nil
 
 }
 
-func downloadImage(url string) (*image.Image, error) {
-	response, err := http.Get(url)
-	if err != nil {
-		return nil, err
+// visit visits the url and returns the context error if the visit was aborted because of it, other failures are
+// classified into a FetchError
+func visit(ctx context.Context, c *colly.Collector, url string) error {
+	var failed *colly.Response
+	c.OnError(func(r *colly.Response, _ error) {
+		failed = r
+	})
+	err := c.Visit(url)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
 	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(response.Body)
+	if err == nil {
+		return nil
+	}
+	if failed != nil && failed.StatusCode >= http.StatusBadRequest {
+		var header http.Header
+		if failed.Headers != nil {
+			header = *failed.Headers
+		}
+		return statusError(url, failed.StatusCode, header)
+	}
+	return networkError(url, err)
+}
 
-	body, err := io.ReadAll(response.Body)
+func downloadImage(ctx context.Context, url string) (*image.Image, error) {
+	body, err := get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 	img, _, err := image.Decode(bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, imageError(url, err)
 	}
 
 	return &img, nil
 }
 
+// get fetches the body of the url, failures are classified into a FetchError
+func get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, networkError(url, err)
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	response, err := httpClient().Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, networkError(url, err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(response.Body)
+
+	if response.StatusCode != http.StatusOK {
+		return nil, statusError(url, response.StatusCode, response.Header)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, networkError(url, err)
+	}
+	return body, nil
+}
+
 func defaultConvertorOptions() *convert.Options {
 	convertOptions := convert.DefaultOptions
 	convertOptions.FixedWidth = 40
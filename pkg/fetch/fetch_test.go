@@ -0,0 +1,135 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/replay"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/selectors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const (
+	fixturesDir = "test_data/fixtures"
+	goldenDir   = "test_data/golden"
+)
+
+// fixedNow is the time the fixtures are "fetched" at so that the timestamps are stable
+var fixedNow = time.Date(2024, 10, 1, 12, 0, 0, 0, dates.Location)
+
+func setupReplay(t *testing.T) string {
+	srv := replay.NewServer(fixturesDir)
+	now = func() time.Time { return fixedNow }
+	t.Cleanup(func() {
+		srv.Close()
+		now = time.Now
+	})
+	return srv.URL
+}
+
+// assertGolden compares the value to the golden file or rewrites it with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	fp := filepath.Join(goldenDir, name)
+	if *update {
+		if err := os.MkdirAll(goldenDir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(fp)
+	if err != nil {
+		t.Fatalf("could not read golden file %s, run with -update to create it: %s", fp, err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("%s does not match golden file, got:\n%s", name, got)
+	}
+}
+
+func asJson(t *testing.T, v any) []byte {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(b, '\n')
+}
+
+func TestSyncEvents(t *testing.T) {
+	url := setupReplay(t)
+
+	events, err := Sync.Events(context.Background(), url+"/")
+	if err != nil {
+		t.Fatalf("err fetching events: %s", err)
+	}
+	if len(events) != 3 {
+		t.Errorf("expected 3 events, got %d", len(events))
+	}
+	assertGolden(t, "events.json", asJson(t, events))
+}
+
+func TestSyncEventDetails(t *testing.T) {
+	url := setupReplay(t)
+
+	ed, err := Sync.EventDetails(context.Background(), url+"/tapahtuma/band-one-18-10-2024/", "band-one-18-10-2024")
+	if err != nil {
+		t.Fatalf("err fetching details: %s", err)
+	}
+	assertGolden(t, "event_details.json", asJson(t, ed))
+}
+
+func TestSyncEventImage(t *testing.T) {
+	url := setupReplay(t)
+
+	ea, err := Sync.EventImage(context.Background(), url+"/wp-content/uploads/2024/08/band-one.png", "band-one-18-10-2024")
+	if err != nil {
+		t.Fatalf("err fetching image: %s", err)
+	}
+	assertGolden(t, "event_ascii.txt", []byte(ea.Ascii))
+}
+
+func TestSyncMissingFixture(t *testing.T) {
+	url := setupReplay(t)
+
+	_, err := Sync.EventImage(context.Background(), url+"/missing.png", "missing")
+	if err == nil {
+		t.Errorf("expected err for missing image")
+	}
+}
+
+func TestCheckSelectors(t *testing.T) {
+	url := setupReplay(t)
+
+	checks, err := CheckSelectors(context.Background(), selectors.Default(), url+"/", url+"/tapahtuma/band-one-18-10-2024/")
+	if err != nil {
+		t.Fatalf("err checking selectors: %s", err)
+	}
+	for _, c := range checks {
+		if c.Missed() {
+			t.Errorf("selector %s (%s) matched nothing on %s", c.Name, c.Selector, c.Page)
+		}
+	}
+
+	broken := *selectors.Default()
+	broken.EventDate = "p.when"
+	checks, _ = CheckSelectors(context.Background(), &broken, url+"/", url+"/tapahtuma/band-one-18-10-2024/")
+	missed := 0
+	for _, c := range checks {
+		if c.Missed() {
+			missed++
+		}
+	}
+	if missed != 1 {
+		t.Errorf("expected one missed selector, got %d", missed)
+	}
+}
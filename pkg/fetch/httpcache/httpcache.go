@@ -0,0 +1,349 @@
+// Package httpcache contains an on-disk HTTP cache used as a transport for the fetches. Fresh responses are served
+// from the disk and stale ones are revalidated with conditional requests using ETag and Last-Modified.
+package httpcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheHeader is set on the responses served by the cache, the value is one of the Status constants
+const CacheHeader = "X-Lutakkols-Cache"
+
+const (
+	StatusHit         = "hit"
+	StatusRevalidated = "revalidated"
+	StatusMiss        = "miss"
+)
+
+type revalidateKey struct{}
+
+// WithRevalidate makes the requests with the ctx revalidate the cached responses even if they are fresh
+func WithRevalidate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, revalidateKey{}, true)
+}
+
+func revalidate(ctx context.Context) bool {
+	v, _ := ctx.Value(revalidateKey{}).(bool)
+	return v
+}
+
+// Transport caches the GET responses into Dir, the cache is pruned to MaxBytes of bodies by removing the least
+// recently used entries. Responses without freshness information are fresh for DefaultTTL.
+type Transport struct {
+	Dir        string
+	MaxBytes   int64
+	DefaultTTL time.Duration
+	Base       http.RoundTripper
+
+	now      func() time.Time
+	pruneMtx sync.Mutex
+}
+
+// New creates the cache transport over the base transport
+func New(dir string, maxBytes int64, defaultTTL time.Duration, base http.RoundTripper) *Transport {
+	return &Transport{
+		Dir:        dir,
+		MaxBytes:   maxBytes,
+		DefaultTTL: defaultTTL,
+		Base:       base,
+		now:        time.Now,
+	}
+}
+
+type entry struct {
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+type cacheControl struct {
+	noStore bool
+	noCache bool
+	maxAge  time.Duration
+	hasAge  bool
+}
+
+func parseCacheControl(value string) cacheControl {
+	var cc cacheControl
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "no-store":
+			cc.noStore = true
+		case "no-cache":
+			cc.noCache = true
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(arg, `"`)); err == nil {
+				cc.maxAge = time.Duration(secs) * time.Second
+				cc.hasAge = true
+			}
+		}
+	}
+	return cc
+}
+
+// ttl resolves how long the entry is fresh from Cache-Control, Expires or the default
+func (t *Transport) ttl(e entry) time.Duration {
+	cc := parseCacheControl(e.Header.Get("Cache-Control"))
+	switch {
+	case cc.noCache:
+		return 0
+	case cc.hasAge:
+		return cc.maxAge
+	}
+	if expires, err := http.ParseTime(e.Header.Get("Expires")); err == nil {
+		if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+			return expires.Sub(date)
+		}
+		return expires.Sub(e.StoredAt)
+	}
+	return t.DefaultTTL
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) paths(u string) (string, string) {
+	hash := sha256.Sum256([]byte(u))
+	key := hex.EncodeToString(hash[:])
+	return filepath.Join(t.Dir, key+".json"), filepath.Join(t.Dir, key+".body")
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base().RoundTrip(req)
+	}
+
+	u := req.URL.String()
+	cached, body, err := t.load(u)
+	if err != nil {
+		return t.fetch(req)
+	}
+
+	if !revalidate(req.Context()) && t.now().Sub(cached.StoredAt) < t.ttl(cached) {
+		t.touch(u)
+		return cachedResponse(req, cached, body, StatusHit), nil
+	}
+
+	etag := cached.Header.Get("ETag")
+	lastModified := cached.Header.Get("Last-Modified")
+	if len(etag) == 0 && len(lastModified) == 0 {
+		return t.fetch(req)
+	}
+
+	conditional := req.Clone(req.Context())
+	if len(etag) > 0 {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if len(lastModified) > 0 {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+	res, err := t.base().RoundTrip(conditional)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusNotModified {
+		return t.store(req, res)
+	}
+	_ = res.Body.Close()
+
+	// the 304 response carries the updated caching headers
+	for _, h := range []string{"Cache-Control", "Expires", "Date", "ETag", "Last-Modified"} {
+		if v := res.Header.Get(h); len(v) > 0 {
+			cached.Header.Set(h, v)
+		}
+	}
+	cached.StoredAt = t.now()
+	_ = t.writeEntry(u, cached, nil)
+	return cachedResponse(req, cached, body, StatusRevalidated), nil
+}
+
+func cachedResponse(req *http.Request, e entry, body []byte, status string) *http.Response {
+	header := e.Header.Clone()
+	header.Set(CacheHeader, status)
+	return &http.Response{
+		Status:        strconv.Itoa(e.Status) + " " + http.StatusText(e.Status),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func (t *Transport) fetch(req *http.Request) (*http.Response, error) {
+	res, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	return t.store(req, res)
+}
+
+// store caches the successful response, the body is read fully to be able to write it
+func (t *Transport) store(req *http.Request, res *http.Response) (*http.Response, error) {
+	cc := parseCacheControl(res.Header.Get("Cache-Control"))
+	if res.StatusCode != http.StatusOK || cc.noStore {
+		if cc.noStore {
+			t.remove(req.URL.String())
+		}
+		return res, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	e := entry{
+		URL:      req.URL.String(),
+		Status:   res.StatusCode,
+		Header:   res.Header.Clone(),
+		StoredAt: t.now(),
+	}
+	if err = t.writeEntry(e.URL, e, body); err == nil {
+		t.prune()
+	}
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.Header.Set(CacheHeader, StatusMiss)
+	return res, nil
+}
+
+func (t *Transport) load(u string) (entry, []byte, error) {
+	var e entry
+	metaPath, bodyPath := t.paths(u)
+	meta, err := os.ReadFile(metaPath)
+	if err != nil {
+		return e, nil, err
+	}
+	if err = json.Unmarshal(meta, &e); err != nil {
+		return e, nil, err
+	}
+	if e.URL != u {
+		return e, nil, errors.New("cache key collision")
+	}
+	body, err := os.ReadFile(bodyPath)
+	return e, body, err
+}
+
+// writeEntry writes the meta and the body if it is not nil, files are renamed into place to not leave partial ones
+func (t *Transport) writeEntry(u string, e entry, body []byte) error {
+	if err := os.MkdirAll(t.Dir, os.ModePerm); err != nil {
+		return err
+	}
+	metaPath, bodyPath := t.paths(u)
+	if body != nil {
+		if err := writeFile(bodyPath, body); err != nil {
+			return err
+		}
+	}
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err = writeFile(metaPath, meta); err != nil {
+		return err
+	}
+	// the entries are pruned by when they were used
+	t.touch(u)
+	return nil
+}
+
+func writeFile(fp string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fp), filepath.Base(fp)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), fp)
+}
+
+// touch marks the entry used so that it is pruned later
+func (t *Transport) touch(u string) {
+	metaPath, _ := t.paths(u)
+	n := t.now()
+	_ = os.Chtimes(metaPath, n, n)
+}
+
+func (t *Transport) remove(u string) {
+	metaPath, bodyPath := t.paths(u)
+	_ = os.Remove(metaPath)
+	_ = os.Remove(bodyPath)
+}
+
+// prune removes the least recently used entries until the bodies fit into MaxBytes, 0 means no limit
+func (t *Transport) prune() {
+	if t.MaxBytes <= 0 {
+		return
+	}
+	t.pruneMtx.Lock()
+	defer t.pruneMtx.Unlock()
+
+	type cached struct {
+		metaPath string
+		bodyPath string
+		used     time.Time
+		size     int64
+	}
+
+	metas, err := filepath.Glob(filepath.Join(t.Dir, "*.json"))
+	if err != nil {
+		return
+	}
+	var entries []cached
+	var total int64
+	for _, m := range metas {
+		mi, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		c := cached{metaPath: m, bodyPath: strings.TrimSuffix(m, ".json") + ".body", used: mi.ModTime()}
+		if bi, err := os.Stat(c.bodyPath); err == nil {
+			c.size = bi.Size()
+		}
+		total += c.size
+		entries = append(entries, c)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].used.Before(entries[j].used)
+	})
+	for _, c := range entries {
+		if total <= t.MaxBytes {
+			return
+		}
+		_ = os.Remove(c.metaPath)
+		_ = os.Remove(c.bodyPath)
+		total -= c.size
+	}
+}
@@ -0,0 +1,146 @@
+package httpcache
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type server struct {
+	*httptest.Server
+	requests    atomic.Int32
+	conditional atomic.Int32
+}
+
+func newServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *server {
+	s := &server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			s.conditional.Add(1)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func get(t *testing.T, c *http.Client, ctx context.Context, u string) (string, string) {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("err getting %s: %s", u, err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	body, _ := io.ReadAll(res.Body)
+	return string(body), res.Header.Get(CacheHeader)
+}
+
+func TestFreshAndRevalidated(t *testing.T) {
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("listing"))
+	})
+
+	now := time.Now()
+	tr := New(t.TempDir(), 0, time.Minute, nil)
+	tr.now = func() time.Time { return now }
+	c := &http.Client{Transport: tr}
+
+	if body, status := get(t, c, context.Background(), srv.URL); body != "listing" || status != StatusMiss {
+		t.Errorf("expected miss, got %s %s", body, status)
+	}
+	if body, status := get(t, c, context.Background(), srv.URL); body != "listing" || status != StatusHit {
+		t.Errorf("expected hit, got %s %s", body, status)
+	}
+	if srv.requests.Load() != 1 {
+		t.Errorf("fresh response should not be requested again")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if body, status := get(t, c, context.Background(), srv.URL); body != "listing" || status != StatusRevalidated {
+		t.Errorf("expected revalidation, got %s %s", body, status)
+	}
+	if body, status := get(t, c, WithRevalidate(context.Background()), srv.URL); body != "listing" || status != StatusRevalidated {
+		t.Errorf("expected forced revalidation, got %s %s", body, status)
+	}
+	if srv.requests.Load() != 3 || srv.conditional.Load() != 2 {
+		t.Errorf("expected two conditional requests, got %d of %d", srv.conditional.Load(), srv.requests.Load())
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/max-age":
+			w.Header().Set("Cache-Control", "public, max-age=0")
+			w.Header().Set("Last-Modified", "Tue, 01 Oct 2024 09:00:00 GMT")
+			if r.Header.Get("If-Modified-Since") != "" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	})
+
+	tr := New(t.TempDir(), 0, time.Hour, nil)
+	c := &http.Client{Transport: tr}
+
+	for i := 0; i < 2; i++ {
+		get(t, c, context.Background(), srv.URL+"/no-store")
+		get(t, c, context.Background(), srv.URL+"/error")
+	}
+	if srv.requests.Load() != 4 {
+		t.Errorf("no-store and failed responses should not be cached, got %d requests", srv.requests.Load())
+	}
+
+	get(t, c, context.Background(), srv.URL+"/max-age")
+	if _, status := get(t, c, context.Background(), srv.URL+"/max-age"); status != StatusRevalidated {
+		t.Errorf("max-age=0 should be revalidated, got %s", status)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	})
+
+	dir := t.TempDir()
+	now := time.Now()
+	tr := New(dir, 250, time.Hour, nil)
+	tr.now = func() time.Time { return now }
+	c := &http.Client{Transport: tr}
+
+	for _, p := range []string{"/a", "/b", "/c", "/d"} {
+		get(t, c, context.Background(), srv.URL+p)
+		now = now.Add(time.Minute)
+	}
+
+	bodies, _ := filepath.Glob(filepath.Join(dir, "*.body"))
+	if len(bodies) != 2 {
+		t.Errorf("expected the cache to be pruned into 2 entries, got %d", len(bodies))
+	}
+	if _, status := get(t, c, context.Background(), srv.URL+"/d"); status != StatusHit {
+		t.Errorf("latest entry should be kept, got %s", status)
+	}
+	if _, status := get(t, c, context.Background(), srv.URL+"/a"); status != StatusMiss {
+		t.Errorf("oldest entry should be pruned, got %s", status)
+	}
+}
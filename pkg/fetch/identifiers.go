@@ -1,53 +1,88 @@
 package fetch
 
 import (
-	"bytes"
 	"crypto/sha256"
-	"encoding/gob"
 	"encoding/hex"
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
-	"reflect"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
 )
 
-func createEventID(event models.Event) (string, error) {
-	wid := withoutID(event)
-	return hashStruct(wid)
+// EventID resolves a stable identity for the event. The identity is derived from the slug of the canonical
+// event page URL so that it survives changes in stock status or bullet points, if the URL is not usable
+// it falls back to a hash of the headline and the day of the event.
+func EventID(event models.Event) string {
+	if slug := eventSlug(event.EventLink); len(slug) > 0 {
+		return slug
+	}
+	return fallbackID(event.Headline, eventDay(event))
 }
 
-// hashStruct calculates the SHA256 hash of the given struct and returns it as a hex string,
-// skipping the key field.
-func hashStruct(data interface{}) (string, error) {
-	var buf bytes.Buffer
+func createEventID(event models.Event) string {
+	return EventID(event)
+}
 
-	enc := gob.NewEncoder(&buf)
-	if err := enc.Encode(data); err != nil {
-		return "", err
+// eventSlug returns the normalized last path segment of the event url or empty string if there is none
+func eventSlug(eventURL string) string {
+	u, err := url.Parse(strings.TrimSpace(eventURL))
+	if err != nil {
+		return ""
 	}
 
-	hash := sha256.Sum256(buf.Bytes())
-	return hex.EncodeToString(hash[:]), nil
-}
+	var last string
+	for _, s := range strings.Split(u.Path, "/") {
+		if len(s) > 0 {
+			last = s
+		}
+	}
 
-func withoutID(data interface{}) interface{} {
-	return filterField(data, "Id")
+	if unescaped, err := url.PathUnescape(last); err == nil {
+		last = unescaped
+	}
+	return normalizeSlug(last)
 }
 
-// filterField creates a copy of the struct with the key field zeroed out.
-func filterField(data interface{}, skipField string) interface{} {
-	v := reflect.ValueOf(data)
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
+// normalizeSlug lowercases the slug and collapses everything else than letters and digits into single dashes
+func normalizeSlug(slug string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(slug) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && sb.Len() > 0 {
+				sb.WriteRune('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
 	}
-	t := v.Type()
-
-	newStruct := reflect.New(t).Elem()
+	return sb.String()
+}
 
-	for i := 0; i < v.NumField(); i++ {
-		field := t.Field(i)
-		if field.Name != skipField {
-			newStruct.Field(i).Set(v.Field(i))
-		}
+// eventDay is the day of the event with the year, the year of a date like "1.1." is inferred from when the event was
+// listed so that the events of different years do not get the same identity. The date is used as it is when it can
+// not be parsed.
+func eventDay(event models.Event) string {
+	if event.StartsAt != nil {
+		return event.StartsAt.In(dates.Location).Format(time.DateOnly)
 	}
+	ref := event.UpdatedAt
+	if ref.IsZero() {
+		ref = time.Now()
+	}
+	if d, err := dates.ParseDay(event.Date, ref); err == nil {
+		return d.Format(time.DateOnly)
+	}
+	return event.Date
+}
 
-	return newStruct.Interface()
+// fallbackID calculates the SHA256 hash of the normalized headline and date and returns it as a hex string
+func fallbackID(headline string, date string) string {
+	key := strings.ToLower(cleanupHeadline(strings.TrimSpace(headline))) + "|" + strings.TrimSpace(date)
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
 }
@@ -2,6 +2,7 @@ package fetch
 
 import (
 	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
 	"testing"
 	"time"
 )
@@ -11,41 +12,76 @@ func TestCreateEventID(t *testing.T) {
 		Id:             "abc",
 		Order:          0,
 		Headline:       "headline",
-		EventLink:      "eventLink",
+		EventLink:      "https://www.jelmu.net/tapahtuma/Band-Name_2024/",
 		SmallImageLink: "imageLink",
 		Weekday:        "weekDay",
 		Date:           "date",
 		StoreLink:      "storeLink",
 		InStock:        true,
+		BulletPoints:   []string{"K18"},
 		UpdatedAt:      time.Now(),
 	}
 
 	b := a
 	b.Id = "another"
+	b.InStock = false
+	b.BulletPoints = []string{"K18", "Loppuunmyyty"}
+	b.Order = 3
 
-	aId, err := createEventID(a)
-	if err != nil {
-		t.Errorf("Err creating event ID: %s", err)
-	}
-	bId, err := createEventID(b)
-	if err != nil {
-		t.Errorf("Err creating event ID: %s", err)
-	}
+	aId := createEventID(a)
+	bId := createEventID(b)
 
 	if aId != bId {
-		t.Errorf("Event IDs are not the same with same content, other than ID")
+		t.Errorf("Event IDs are not the same with same event link")
 	}
 
-	c := a
-	c.Id = "new_id"
-	c.Headline = "new headline"
-	cId, err := createEventID(c)
-	if err != nil {
-		t.Errorf("Err creating event ID: %s", err)
+	if aId != "band-name-2024" {
+		t.Errorf("Event ID was not the normalized slug: %s", aId)
 	}
 
+	c := a
+	c.EventLink = "https://www.jelmu.net/tapahtuma/another-band/"
+	cId := createEventID(c)
+
 	if aId == cId {
-		t.Errorf("Event IDs are the same with different content")
+		t.Errorf("Event IDs are the same with different event link")
+	}
+
+}
+
+func TestCreateEventIDFallback(t *testing.T) {
+	a := models.Event{
+		Headline: "headline",
+		Date:     "1.1.",
+		InStock:  true,
 	}
 
+	b := a
+	b.InStock = false
+	b.Headline = " headline "
+
+	if createEventID(a) != createEventID(b) {
+		t.Errorf("Fallback IDs are not the same with same headline and date")
+	}
+
+	c := a
+	c.Date = "2.1."
+	if createEventID(a) == createEventID(c) {
+		t.Errorf("Fallback IDs are the same with different date")
+	}
+
+	// the same day of another year is another event
+	d := a
+	d.UpdatedAt = time.Date(2024, 12, 1, 12, 0, 0, 0, dates.Location)
+	e := a
+	e.UpdatedAt = time.Date(2025, 12, 1, 12, 0, 0, 0, dates.Location)
+	if createEventID(d) == createEventID(e) {
+		t.Errorf("Fallback IDs are the same for different years")
+	}
+	startsAt := time.Date(2025, 1, 1, 20, 0, 0, 0, dates.Location)
+	f := a
+	f.StartsAt = &startsAt
+	if createEventID(d) != createEventID(f) {
+		t.Errorf("Fallback IDs differ for the same day with and without the start time")
+	}
 }
@@ -0,0 +1,143 @@
+// Package replay contains a recording transport to capture responses from the source into a fixtures directory
+// and a server to replay them, it is used to test the scraping against the markup of the site
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	metaSuffix = ".json"
+	bodySuffix = ".body"
+)
+
+// recordedHeaders are the only headers stored so that fixtures do not contain cookies and such
+var recordedHeaders = []string{"Content-Type", "Last-Modified", "Etag", "Cache-Control"}
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// meta is the recorded response without the body, Synthetic marks the fixtures written by hand instead of recorded
+// from the site, recording the url again replaces them
+type meta struct {
+	URL       string      `json:"url"`
+	Status    int         `json:"status"`
+	Synthetic bool        `json:"synthetic,omitempty"`
+	Header    http.Header `json:"header"`
+}
+
+// Key returns the fixture name for the url, only the path and query are used so that the fixtures
+// can be replayed from any host
+func Key(u *url.URL) string {
+	p := strings.Trim(u.EscapedPath(), "/")
+	if len(u.RawQuery) > 0 {
+		p += "?" + u.RawQuery
+	}
+	if len(p) == 0 {
+		return "index"
+	}
+	return strings.Trim(unsafeChars.ReplaceAllString(p, "_"), "_")
+}
+
+// Recorder is a http.RoundTripper which stores every response into Dir
+type Recorder struct {
+	Dir  string
+	Base http.RoundTripper
+}
+
+func (r Recorder) base() http.RoundTripper {
+	if r.Base == nil {
+		return http.DefaultTransport
+	}
+	return r.Base
+}
+
+func (r Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := r.base().RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err = r.save(req.URL, res, body); err != nil {
+		return nil, fmt.Errorf("could not record %s: %w", req.URL, err)
+	}
+	return res, nil
+}
+
+func (r Recorder) save(u *url.URL, res *http.Response, body []byte) error {
+	if err := os.MkdirAll(r.Dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	m := meta{
+		URL:    u.String(),
+		Status: res.StatusCode,
+		Header: http.Header{},
+	}
+	for _, h := range recordedHeaders {
+		if v := res.Header.Get(h); len(v) > 0 {
+			m.Header.Set(h, v)
+		}
+	}
+	mb, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	base := filepath.Join(r.Dir, Key(u))
+	if err = os.WriteFile(base+metaSuffix, mb, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(base+bodySuffix, body, 0644)
+}
+
+// Handler serves the fixtures recorded into dir, unknown fixtures are responded with 404
+func Handler(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		base := filepath.Join(dir, Key(req.URL))
+		mb, err := os.ReadFile(base + metaSuffix)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("no fixture for %s", req.URL), http.StatusNotFound)
+			return
+		}
+		var m meta
+		if err = json.Unmarshal(mb, &m); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, err := os.ReadFile(base + bodySuffix)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for k, vs := range m.Header {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+		w.WriteHeader(m.Status)
+		_, _ = w.Write(body)
+	})
+}
+
+// NewServer starts a server replaying the fixtures in dir, remember to Close it
+func NewServer(dir string) *httptest.Server {
+	return httptest.NewServer(Handler(dir))
+}
@@ -0,0 +1,62 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := map[string]string{
+		"https://www.jelmu.net/":                         "index",
+		"https://www.jelmu.net/tapahtuma/band-one/":      "tapahtuma_band-one",
+		"https://www.jelmu.net/kauppa/?add-to-cart=101":  "kauppa_add-to-cart_101",
+		"https://www.jelmu.net/wp-content/uploads/a.png": "wp-content_uploads_a.png",
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := Key(u); got != want {
+			t.Errorf("key for %s was %s, want %s", raw, got, want)
+		}
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Set-Cookie", "secret")
+		_, _ = w.Write([]byte("hello " + r.URL.Path))
+	}))
+	defer origin.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: Recorder{Dir: dir}}
+	res, err := client.Get(origin.URL + "/page/")
+	if err != nil {
+		t.Fatalf("err recording: %s", err)
+	}
+	_ = res.Body.Close()
+
+	srv := NewServer(dir)
+	defer srv.Close()
+
+	res, err = http.Get(srv.URL + "/page/")
+	if err != nil {
+		t.Fatalf("err replaying: %s", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if string(body) != "hello /page/" {
+		t.Errorf("invalid replayed body %s", body)
+	}
+	if res.Header.Get("Set-Cookie") != "" {
+		t.Errorf("cookies should not be recorded")
+	}
+
+	res, _ = http.Get(srv.URL + "/missing")
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for missing fixture, got %d", res.StatusCode)
+	}
+}
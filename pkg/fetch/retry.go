@@ -0,0 +1,89 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how transient failures are retried, the delay doubles from BaseDelay up to MaxDelay for each
+// retry with jitter. Retries is the amount of retries after the first attempt. A Retry-After of the source longer
+// than MaxRetryAfter is not waited for and the failure is returned as it is.
+type RetryPolicy struct {
+	Retries       int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy is used unless replaced with SetRetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	Retries:       2,
+	BaseDelay:     500 * time.Millisecond,
+	MaxDelay:      10 * time.Second,
+	MaxRetryAfter: time.Minute,
+}
+
+var retryPolicy atomic.Pointer[RetryPolicy]
+
+func init() {
+	SetRetryPolicy(DefaultRetryPolicy)
+}
+
+// SetRetryPolicy replaces the policy used for the fetches
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicy.Store(&p)
+}
+
+// delay returns the backoff before the retry with full jitter over the upper half
+func (p RetryPolicy) delay(retry int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay << retry
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// withRetry runs the fetch until it succeeds, fails permanently, runs out of retries or the ctx is done
+func withRetry[T any](ctx context.Context, fetchFn func() (T, error)) (T, error) {
+	p := *retryPolicy.Load()
+	for retry := 0; ; retry++ {
+		v, err := fetchFn()
+		if err == nil || retry >= p.Retries || !IsTransient(err) || ctx.Err() != nil {
+			return v, err
+		}
+
+		wait := p.delay(retry)
+		var fe *FetchError
+		if errors.As(err, &fe) && fe.RetryAfter > p.MaxRetryAfter {
+			logger.Log.Warnf("not waiting for Retry-After of %v: %v", fe.RetryAfter, err)
+			return v, err
+		}
+		if fe != nil && fe.RetryAfter > wait {
+			wait = fe.RetryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			// the source would not be ready before the caller gives up
+			return v, err
+		}
+
+		logger.Log.Warnf("retrying in %v: %v", wait, err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return v, err
+		case <-timer.C:
+		}
+	}
+}
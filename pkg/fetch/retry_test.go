@@ -0,0 +1,168 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first requests with the status and then serves the listing fixture
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	listing, err := os.ReadFile(fixturesDir + "/index.body")
+	if err != nil {
+		t.Fatalf("could not read listing fixture: %s", err)
+	}
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, vs := range header {
+				w.Header()[k] = vs
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write(listing)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func useRetryPolicy(t *testing.T, p RetryPolicy) {
+	SetRetryPolicy(p)
+	t.Cleanup(func() {
+		SetRetryPolicy(DefaultRetryPolicy)
+	})
+}
+
+func TestRetryTransientStatus(t *testing.T) {
+	useRetryPolicy(t, RetryPolicy{Retries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+	srv, calls := flakyServer(t, 2, http.StatusServiceUnavailable, nil)
+
+	events, err := Sync.Events(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("expected the retries to succeed, got: %s", err)
+	}
+	if len(events) == 0 {
+		t.Errorf("expected events after retrying")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", calls.Load())
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	useRetryPolicy(t, RetryPolicy{Retries: 1, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+	srv, calls := flakyServer(t, 5, http.StatusBadGateway, nil)
+
+	_, err := Sync.Events(context.Background(), srv.URL)
+	var fe *FetchError
+	if !errors.As(err, &fe) || fe.Kind != KindStatus || fe.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected a status error, got: %v", err)
+	}
+	if !IsTransient(err) {
+		t.Errorf("expected 502 to be transient")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 requests, got %d", calls.Load())
+	}
+}
+
+func TestNoRetryForPermanentFailures(t *testing.T) {
+	useRetryPolicy(t, RetryPolicy{Retries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+	srv, calls := flakyServer(t, 5, http.StatusNotFound, nil)
+
+	_, err := Sync.Events(context.Background(), srv.URL)
+	if KindOf(err) != KindStatus || IsTransient(err) {
+		t.Errorf("expected a permanent status error, got: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single request, got %d", calls.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	useRetryPolicy(t, RetryPolicy{Retries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxRetryAfter: 2 * time.Minute})
+	srv, _ := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}})
+
+	start := time.Now()
+	if _, err := Sync.Events(context.Background(), srv.URL); err != nil {
+		t.Fatalf("expected the retry to succeed, got: %s", err)
+	}
+	if time.Since(start) < time.Second {
+		t.Errorf("expected to wait for the Retry-After")
+	}
+
+	// the deadline comes before the source is ready again so there is no point waiting
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"60"}})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := Sync.Events(ctx, srv.URL); KindOf(err) != KindStatus {
+		t.Errorf("expected the status error, got: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single request, got %d", calls.Load())
+	}
+
+	// without a deadline a Retry-After over the limit is not waited for either
+	srv, calls = flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"86400"}})
+	start = time.Now()
+	if _, err := Sync.Events(context.Background(), srv.URL); KindOf(err) != KindStatus {
+		t.Errorf("expected the status error, got: %v", err)
+	}
+	if calls.Load() != 1 || time.Since(start) > 10*time.Second {
+		t.Errorf("expected to give up without waiting, got %d requests in %v", calls.Load(), time.Since(start))
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	url := setupReplay(t)
+
+	_, err := Sync.Events(context.Background(), url+"/missing.png")
+	if KindOf(err) != KindStatus {
+		t.Errorf("expected status error for missing page, got: %v", err)
+	}
+
+	_, err = Sync.EventDetails(context.Background(), url+"/", "listing")
+	if KindOf(err) != KindParse {
+		t.Errorf("expected parse error for a page without details, got: %v", err)
+	}
+
+	_, err = Sync.EventImage(context.Background(), url+"/", "listing")
+	if KindOf(err) != KindImage {
+		t.Errorf("expected image error for a page which is not an image, got: %v", err)
+	}
+
+	_, err = Sync.Events(context.Background(), "http://127.0.0.1:1/")
+	if KindOf(err) != KindNetwork || !IsTransient(err) {
+		t.Errorf("expected transient network error, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Sync.Events(ctx, url+"/")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context error, got: %v", err)
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	ref := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-5":                            0,
+		"Tue, 01 Oct 2024 12:00:30 GMT": 30 * time.Second,
+		"Tue, 01 Oct 2024 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for value, want := range tests {
+		if got := retryAfter(value, ref); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -1,26 +1,138 @@
-// Package selectors contains the selectors for source HTML
+// Package selectors contains the selectors for source HTML, they are loaded from a versioned profile so that
+// changes in the markup can be patched without a new release
 package selectors
 
-const (
-	EventSummary           = ".summary"
-	EventProductInfoMobile = ".product-info-mobile"
-	EventProductInfo       = ".product-info"
-	Events                 = ".products li"
-	OutOfStock             = "p.out-of-stock"
-	ParagraphNoClass       = "p:not([class])"
-	ImageLink              = "img"
-	EventProductInfoParts  = "span"
-	EventLink              = "a:first-child"
-	EventStoreLink         = "a:nth-child(2)"
-	Headline2              = "h2:first-child"
-	BulletPoints           = "div:last-child > span"
-	EventSmallImage        = "img"
-	EventWeekDay           = "p.datetime > span:first-child"
-	EventDate              = "p.datetime > span.date"
-	EventHeadliners        = "h2 span"
-	EventBackupHeadliners  = "h2"
-	EventPlayTimes         = ".play-times > div > p"
-	EventTickets           = ".variations_form"
-	DoorPrice              = ".add-to-cart-wrapper"
-	TicketPrices           = ".single-variation"
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+	"os"
+	"reflect"
+	"sync/atomic"
 )
+
+// SupportedVersion is the profile version this build understands
+const SupportedVersion = 1
+
+//go:embed default.yaml
+var defaultProfile []byte
+
+// Profile is a set of selectors for the source, it can be written either in YAML or JSON
+type Profile struct {
+	Version int    `yaml:"version"`
+	Name    string `yaml:"name"`
+
+	Events                string `yaml:"events"`
+	EventLink             string `yaml:"event_link"`
+	EventStoreLink        string `yaml:"event_store_link"`
+	EventSmallImage       string `yaml:"event_small_image"`
+	EventWeekDay          string `yaml:"event_week_day"`
+	EventDate             string `yaml:"event_date"`
+	EventHeadliners       string `yaml:"event_headliners"`
+	EventBackupHeadliners string `yaml:"event_backup_headliners"`
+	BulletPoints          string `yaml:"bullet_points"`
+	OutOfStock            string `yaml:"out_of_stock"`
+
+	EventSummary           string `yaml:"event_summary"`
+	EventProductInfoMobile string `yaml:"event_product_info_mobile"`
+	ParagraphNoClass       string `yaml:"paragraph_no_class"`
+	ImageLink              string `yaml:"image_link"`
+	EventProductInfo       string `yaml:"event_product_info"`
+	EventProductInfoParts  string `yaml:"event_product_info_parts"`
+	EventPlayTimes         string `yaml:"event_play_times"`
+	EventTickets           string `yaml:"event_tickets"`
+	TicketPrices           string `yaml:"ticket_prices"`
+	TicketDescription      string `yaml:"ticket_description"`
+	TicketPrice            string `yaml:"ticket_price"`
+	DoorPrice              string `yaml:"door_price"`
+}
+
+var current atomic.Pointer[Profile]
+
+func init() {
+	p, err := Parse(defaultProfile)
+	if err != nil {
+		panic(fmt.Sprintf("embedded selector profile is invalid: %v", err))
+	}
+	current.Store(p)
+}
+
+// Current returns the profile in use
+func Current() *Profile {
+	return current.Load()
+}
+
+// Use replaces the profile in use
+func Use(p *Profile) {
+	current.Store(p)
+}
+
+// Default returns the embedded default profile
+func Default() *Profile {
+	p, _ := Parse(defaultProfile)
+	return p
+}
+
+// Load reads and validates a profile from a YAML or JSON file
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector profile %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse parses and validates a profile, unknown keys are errors so that typos do not go unnoticed
+func Parse(data []byte) (*Profile, error) {
+	var p Profile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks that the profile is of supported version and every selector is set and valid CSS
+func (p *Profile) Validate() error {
+	if p.Version != SupportedVersion {
+		return fmt.Errorf("unsupported profile version %d, expected %d", p.Version, SupportedVersion)
+	}
+
+	var errs []error
+	for name, sel := range p.Selectors() {
+		if len(sel) == 0 {
+			errs = append(errs, fmt.Errorf("selector %s is empty", name))
+			continue
+		}
+		if _, err := cascadia.Compile(sel); err != nil {
+			errs = append(errs, fmt.Errorf("selector %s is invalid: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Selectors returns the selectors of the profile by their key
+func (p *Profile) Selectors() map[string]string {
+	rs := make(map[string]string)
+	v := reflect.ValueOf(*p)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() != reflect.String || f.Name == "Name" {
+			continue
+		}
+		rs[f.Tag.Get("yaml")] = v.Field(i).String()
+	}
+	return rs
+}
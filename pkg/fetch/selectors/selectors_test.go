@@ -0,0 +1,39 @@
+package selectors
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	p := Default()
+	if p == nil || p.Events != ".products li" {
+		t.Errorf("embedded profile was not loaded: %+v", p)
+	}
+	if Current().Events != p.Events {
+		t.Errorf("current profile is not the default")
+	}
+}
+
+func TestParse(t *testing.T) {
+	json := strings.Replace(string(defaultProfile), `events: ".products li"`, `events: ".listing article"`, 1)
+	p, err := Parse([]byte(json))
+	if err != nil {
+		t.Fatalf("err parsing profile: %s", err)
+	}
+	if p.Events != ".listing article" {
+		t.Errorf("selector was not overridden")
+	}
+
+	invalid := map[string]string{
+		"unknown key":     string(defaultProfile) + "\nunknown_key: \"p\"\n",
+		"invalid css":     strings.Replace(string(defaultProfile), `"h2 span"`, `"h2 >> span["`, 1),
+		"empty selector":  strings.Replace(string(defaultProfile), `"h2 span"`, `""`, 1),
+		"invalid version": strings.Replace(string(defaultProfile), "version: 1", "version: 2", 1),
+	}
+	for name, data := range invalid {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("expected err for %s", name)
+		}
+	}
+}
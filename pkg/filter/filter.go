@@ -0,0 +1,115 @@
+// Package filter selects events by their date, stock and text
+package filter
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter matches the events, zero values match everything. From and To are inclusive days, events without a
+// parseable date do not match when either is set.
+type Filter struct {
+	From    *time.Time
+	To      *time.Time
+	InStock *bool
+	Text    string
+}
+
+// Day parses a day for From or To, for example "2024-10-18" or "18.10.2024"
+func Day(value string) (*time.Time, error) {
+	d, err := dates.ParseDay(value, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Empty tells if the filter matches everything
+func (f Filter) Empty() bool {
+	return f.From == nil && f.To == nil && f.InStock == nil && len(f.Text) == 0
+}
+
+// Key is the same for the filters which match the same events, for example "from=2024-10-18&in_stock=true"
+func (f Filter) Key() string {
+	var parts []string
+	if f.From != nil {
+		parts = append(parts, "from="+f.From.Format(time.DateOnly))
+	}
+	if f.To != nil {
+		parts = append(parts, "to="+f.To.Format(time.DateOnly))
+	}
+	if f.InStock != nil {
+		parts = append(parts, "in_stock="+strconv.FormatBool(*f.InStock))
+	}
+	if text := strings.ToLower(strings.TrimSpace(f.Text)); len(text) > 0 {
+		parts = append(parts, "q="+text)
+	}
+	return strings.Join(parts, "&")
+}
+
+// Match tells if the event matches all the conditions of the filter
+func (f Filter) Match(e models.Event) bool {
+	if f.InStock != nil && e.InStock != *f.InStock {
+		return false
+	}
+	if f.From != nil || f.To != nil {
+		day, ok := StartDay(e)
+		if !ok {
+			return false
+		}
+		if f.From != nil && day.Before(*f.From) {
+			return false
+		}
+		if f.To != nil && day.After(*f.To) {
+			return false
+		}
+	}
+	if len(f.Text) > 0 && !containsText(e, f.Text) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the matching events in their original order
+func (f Filter) Apply(events []models.Event) []models.Event {
+	if f.Empty() {
+		return events
+	}
+	var rs []models.Event
+	for _, e := range events {
+		if f.Match(e) {
+			rs = append(rs, e)
+		}
+	}
+	return rs
+}
+
+// StartDay returns the midnight of the day the event starts, the raw Date is parsed when StartsAt is missing
+func StartDay(e models.Event) (time.Time, bool) {
+	if e.StartsAt != nil {
+		t := e.StartsAt.In(dates.Location)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, dates.Location), true
+	}
+	ref := e.UpdatedAt
+	if ref.IsZero() {
+		ref = time.Now()
+	}
+	d, err := dates.ParseDay(e.Date, ref)
+	return d, err == nil
+}
+
+func containsText(e models.Event, text string) bool {
+	text = strings.ToLower(text)
+	if strings.Contains(strings.ToLower(e.Headline), text) {
+		return true
+	}
+	for _, bp := range e.BulletPoints {
+		if strings.Contains(strings.ToLower(bp), text) {
+			return true
+		}
+	}
+	return false
+}
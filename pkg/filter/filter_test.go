@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/dates"
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	startsAt := time.Date(2024, 10, 18, 20, 0, 0, 0, dates.Location)
+	events := []models.Event{
+		{Id: "one", Headline: "Band One", Date: "18.10.", StartsAt: &startsAt, InStock: true},
+		{Id: "two", Headline: "Band Two", Date: "2024-11-02", BulletPoints: []string{"Special guest: Opener"}},
+		{Id: "three", Headline: "Band Three", Date: "sometime"},
+	}
+	yes := true
+	no := false
+	from, _ := Day("2024-10-18")
+	to, _ := Day("1.11.2024")
+
+	tests := []struct {
+		name string
+		f    Filter
+		want []string
+	}{
+		{"empty", Filter{}, []string{"one", "two", "three"}},
+		{"in stock", Filter{InStock: &yes}, []string{"one"}},
+		{"sold out", Filter{InStock: &no}, []string{"two", "three"}},
+		{"from", Filter{From: from}, []string{"one", "two"}},
+		{"to", Filter{To: to}, []string{"one"}},
+		{"headline", Filter{Text: "band t"}, []string{"two", "three"}},
+		{"bullet point", Filter{Text: "OPENER"}, []string{"two"}},
+		{"combined", Filter{From: from, Text: "two"}, []string{"two"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range tt.f.Apply(events) {
+				got = append(got, e.Id)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	day := func(s string) *time.Time {
+		d, err := Day(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	inStock := true
+
+	a := Filter{From: day("18.10.2024"), InStock: &inStock, Text: " Band "}
+	b := Filter{From: day("2024-10-18"), InStock: &inStock, Text: "band"}
+	if a.Key() != b.Key() {
+		t.Errorf("expected the same key for %q and %q", a.Key(), b.Key())
+	}
+	if a.Key() == (Filter{From: a.From, Text: "band"}).Key() {
+		t.Errorf("expected a different key without in_stock")
+	}
+	if k := (Filter{}).Key(); k != "" {
+		t.Errorf("expected an empty key for the empty filter, got %q", k)
+	}
+}
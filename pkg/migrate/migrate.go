@@ -0,0 +1,94 @@
+// Package migrate contains the methods to rewrite data files produced by older versions of sync
+package migrate
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"github.com/johannessarpola/lutakkols/pkg/writer"
+	"os"
+)
+
+// Result contains the summary of the migration
+type Result struct {
+	Events  int
+	Details int
+	// Changed is the amount of events whose identity was changed
+	Changed int
+	// Orphans are the detail IDs which could not be matched to any event
+	Orphans []string
+}
+
+// EventIDs rewrites the event and event details files so that they use the stable event identities, the original
+// files are kept next to the rewritten ones with a .bak suffix
+func EventIDs(eventsPath string, detailsPath string) (Result, error) {
+	var (
+		result  Result
+		events  []models.Event
+		details []models.EventDetails
+	)
+
+	if err := writer.ReadJson(eventsPath, &events); err != nil {
+		return result, err
+	}
+	if err := writer.ReadJson(detailsPath, &details); err != nil {
+		return result, err
+	}
+
+	ids := make(map[string]string, len(events))
+	seen := make(map[string]bool, len(events))
+	for i, e := range events {
+		newID := fetch.EventID(e)
+		if seen[newID] {
+			logger.Log.Warnf("duplicate identity %s for event %s", newID, e.Headline)
+		}
+		seen[newID] = true
+		if newID != e.Id {
+			result.Changed++
+		}
+		ids[e.Id] = newID
+		events[i].Id = newID
+	}
+
+	for i, ed := range details {
+		newID, ok := ids[ed.EventID]
+		if !ok {
+			if !seen[ed.EventID] {
+				logger.Log.Warnf("no event found for details with id %s", ed.EventID)
+				result.Orphans = append(result.Orphans, ed.EventID)
+			}
+			continue
+		}
+		details[i].EventID = newID
+	}
+
+	result.Events = len(events)
+	result.Details = len(details)
+
+	if result.Changed == 0 {
+		logger.Log.Infof("no identities to migrate in %s", eventsPath)
+		return result, nil
+	}
+
+	for _, fp := range []string{eventsPath, detailsPath} {
+		if err := backup(fp); err != nil {
+			return result, err
+		}
+	}
+
+	if err := writer.WriteJson(events, eventsPath, writer.PrettyPrint); err != nil {
+		return result, err
+	}
+	if err := writer.WriteJson(details, detailsPath, writer.PrettyPrint); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func backup(fp string) error {
+	data, err := os.ReadFile(fp)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fp+".bak", data, 0644)
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"encoding/json"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/writer"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEventIDs(t *testing.T) {
+	dir := t.TempDir()
+	ep := filepath.Join(dir, "events.json")
+	edp := filepath.Join(dir, "event_details.json")
+
+	events := []models.Event{
+		{Id: "oldhash1", Headline: "Band", EventLink: "https://www.jelmu.net/tapahtuma/band/"},
+		{Id: "oldhash2", Headline: "Other", Date: "1.1."},
+	}
+	details := []models.EventDetails{
+		{EventID: "oldhash1"},
+		{EventID: "missing"},
+	}
+	_ = writer.WriteJson(events, ep)
+	_ = writer.WriteJson(details, edp)
+
+	rs, err := EventIDs(ep, edp)
+	if err != nil {
+		t.Fatalf("migration failed: %s", err)
+	}
+	if rs.Changed != 2 {
+		t.Errorf("expected 2 changed, got %d", rs.Changed)
+	}
+	if len(rs.Orphans) != 1 {
+		t.Errorf("expected 1 orphan, got %d", len(rs.Orphans))
+	}
+
+	var migrated []models.EventDetails
+	b, _ := os.ReadFile(edp)
+	_ = json.Unmarshal(b, &migrated)
+	if migrated[0].EventID != "band" {
+		t.Errorf("details id was not migrated: %s", migrated[0].EventID)
+	}
+
+	if _, err := os.Stat(ep + ".bak"); err != nil {
+		t.Errorf("backup was not written")
+	}
+
+	rs, err = EventIDs(ep, edp)
+	if err != nil || rs.Changed != 0 {
+		t.Errorf("migration should be idempotent")
+	}
+}
@@ -0,0 +1,108 @@
+// Package prices contains the parsing of the free-text prices scraped from the source
+package prices
+
+import (
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when the price text does not have any currency as the venues are in Finland
+const DefaultCurrency = "EUR"
+
+var (
+	amountRe   = regexp.MustCompile(`(\d{1,3}(?:[ .]\d{3})+|\d+)(?:[,.](\d{1,2}))?`)
+	currencies = []struct {
+		symbol string
+		code   string
+	}{
+		{"€", "EUR"},
+		{"eur", "EUR"},
+		{"$", "USD"},
+		{"usd", "USD"},
+		{"£", "GBP"},
+		{"gbp", "GBP"},
+	}
+	freeTexts = []string{"vapaa pääsy", "ilmainen", "free"}
+	// markedAmountRe is an amount next to a currency, the only amounts accepted in texts about free entry as the
+	// other numbers in them are age limits or times like in "Free entry before 22"
+	markedAmountRe = regexp.MustCompile(`(?:€|eur|\$|usd|£|gbp)\s*(` + amountRe.String() + `)|(` + amountRe.String() +
+		`)\s*(?:€|eur|\$|usd|£|gbp)`)
+)
+
+// UnparseableError is returned when the text does not contain any price
+type UnparseableError struct {
+	Value string
+}
+
+func (e UnparseableError) Error() string {
+	return fmt.Sprintf("could not parse price from '%s'", e.Value)
+}
+
+// Parse parses the first price from a text like "25,00 €" or "Hinta ovelta 30€", the original text is kept in
+// the result
+func Parse(text string) (models.Price, error) {
+	p := models.Price{Text: text}
+	normalized := strings.ToLower(strings.ReplaceAll(text, "\u00a0", " "))
+
+	var m []string
+	if isFree(normalized) {
+		m = markedAmount(normalized)
+		if m == nil {
+			p.Currency = DefaultCurrency
+			return p, nil
+		}
+	} else if m = amountRe.FindStringSubmatch(normalized); m == nil {
+		return p, UnparseableError{Value: text}
+	}
+
+	whole := strings.NewReplacer(" ", "", ".", "").Replace(m[1])
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return p, UnparseableError{Value: text}
+	}
+	var cents int64
+	if len(m[2]) > 0 {
+		cents, _ = strconv.ParseInt(m[2], 10, 64)
+		if len(m[2]) == 1 {
+			cents *= 10
+		}
+	}
+
+	p.Cents = units*100 + cents
+	p.Currency = currency(normalized)
+	return p, nil
+}
+
+func isFree(normalized string) bool {
+	for _, f := range freeTexts {
+		if strings.Contains(normalized, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// markedAmount finds the first amount next to a currency and returns its submatches like amountRe would
+func markedAmount(normalized string) []string {
+	m := markedAmountRe.FindStringSubmatch(normalized)
+	switch {
+	case m == nil:
+		return nil
+	case len(m[1]) > 0:
+		return []string{m[0], m[2], m[3]}
+	default:
+		return []string{m[0], m[5], m[6]}
+	}
+}
+
+func currency(normalized string) string {
+	for _, c := range currencies {
+		if strings.Contains(normalized, c.symbol) {
+			return c.code
+		}
+	}
+	return DefaultCurrency
+}
@@ -0,0 +1,45 @@
+package prices
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text     string
+		cents    int64
+		currency string
+	}{
+		{"25,00 €", 2500, "EUR"},
+		{"29,90\u00a0€", 2990, "EUR"},
+		{"Hinta ovelta 30€", 3000, "EUR"},
+		{"$50", 5000, "USD"},
+		{"1 200,5 €", 120050, "EUR"},
+		{"15", 1500, "EUR"},
+		{"Vapaa pääsy", 0, "EUR"},
+		{"Vapaa pääsy, K18", 0, "EUR"},
+		{"Free entry before 22", 0, "EUR"},
+		{"Vapaa pääsy klo 22 asti, sen jälkeen 5 €", 500, "EUR"},
+		{"Free before 23, after that €7,50", 750, "EUR"},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(tt.text)
+		if err != nil {
+			t.Errorf("err parsing %s: %s", tt.text, err)
+			continue
+		}
+		if p.Cents != tt.cents || p.Currency != tt.currency {
+			t.Errorf("parsing %s got %d %s, want %d %s", tt.text, p.Cents, p.Currency, tt.cents, tt.currency)
+		}
+		if p.Text != tt.text {
+			t.Errorf("original text was not kept for %s", tt.text)
+		}
+	}
+
+	p, err := Parse("loppuunmyyty")
+	if err == nil {
+		t.Errorf("expected err for unparseable price")
+	}
+	if p.Text != "loppuunmyyty" {
+		t.Errorf("original text was not kept")
+	}
+}
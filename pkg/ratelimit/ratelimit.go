@@ -0,0 +1,77 @@
+// Package ratelimit contains a token bucket to limit the rate of requests made to the sources
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket which is refilled with a token every interval up to burst tokens,
+// a zero or negative interval does not limit at all
+type Bucket struct {
+	mtx      sync.Mutex
+	interval time.Duration
+	burst    float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+// New creates a full bucket
+func New(interval time.Duration, burst int) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Bucket{
+		interval: interval,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (b *Bucket) reserve() time.Duration {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := b.now()
+	b.tokens = min(b.burst, b.tokens+float64(now.Sub(b.last))/float64(b.interval))
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(b.interval))
+}
+
+// cancel returns a reserved token which was not used
+func (b *Bucket) cancel() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// Wait blocks until a token is available or the ctx is done
+func (b *Bucket) Wait(ctx context.Context) error {
+	if b == nil || b.interval <= 0 {
+		return ctx.Err()
+	}
+
+	wait := b.reserve()
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
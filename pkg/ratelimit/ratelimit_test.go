@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	b := New(20*time.Millisecond, 2)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatalf("err waiting: %s", err)
+		}
+	}
+	// two tokens from the burst and three refilled ones
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("unexpected wait of %v", elapsed)
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	b := New(time.Hour, 1)
+	_ = b.Wait(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err == nil {
+		t.Errorf("expected err when the ctx is done")
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	b := New(0, 1)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		_ = b.Wait(context.Background())
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("zero interval should not limit")
+	}
+}
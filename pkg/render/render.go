@@ -0,0 +1,284 @@
+// Package render prints the events for scripts and humans in the formats of the list and show commands
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Format is the output format
+type Format string
+
+const (
+	Table    Format = "table"
+	Json     Format = "json"
+	NDJson   Format = "ndjson"
+	Csv      Format = "csv"
+	Yaml     Format = "yaml"
+	Template Format = "template"
+)
+
+// Formats are the names of the formats accepted by ParseFormat
+var Formats = []Format{Table, Json, NDJson, Csv, Yaml, Template}
+
+// ParseFormat parses the name of the format, "yml" is accepted for yaml
+func ParseFormat(value string) (Format, error) {
+	f := Format(strings.ToLower(value))
+	if f == "yml" {
+		return Yaml, nil
+	}
+	for _, known := range Formats {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q", value)
+}
+
+// Detailed is an event with its details for showing a single event
+type Detailed struct {
+	Event   models.Event         `json:"event"`
+	Details *models.EventDetails `json:"details,omitempty"`
+}
+
+// Renderer writes the values in the format, tmpl is used with the Template format
+type Renderer struct {
+	Format Format
+	tmpl   *template.Template
+}
+
+// New creates the renderer, the template text is required for the Template format and ignored by the others
+func New(f Format, templateText string) (*Renderer, error) {
+	r := &Renderer{Format: f}
+	if f != Template {
+		return r, nil
+	}
+	if len(templateText) == 0 {
+		return nil, fmt.Errorf("template format requires a template")
+	}
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(templateText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	r.tmpl = tmpl
+	return r, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"date": func(layout string, t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(layout)
+	},
+}
+
+// Events writes the events, the template is executed for each of them
+func (r *Renderer) Events(w io.Writer, events []models.Event) error {
+	switch r.Format {
+	case Table:
+		return eventTable(w, events)
+	case Json:
+		return writeJson(w, events, "  ")
+	case NDJson:
+		enc := json.NewEncoder(w)
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Csv:
+		return eventCsv(w, events)
+	case Yaml:
+		return writeYaml(w, events)
+	case Template:
+		for _, e := range events {
+			if err := r.execute(w, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", r.Format)
+	}
+}
+
+// Event writes a single event with its details
+func (r *Renderer) Event(w io.Writer, d Detailed) error {
+	switch r.Format {
+	case Table:
+		return detailTable(w, d)
+	case Json:
+		return writeJson(w, d, "  ")
+	case NDJson:
+		return writeJson(w, d, "")
+	case Csv:
+		return eventCsv(w, []models.Event{d.Event})
+	case Yaml:
+		return writeYaml(w, d)
+	case Template:
+		return r.execute(w, d)
+	default:
+		return fmt.Errorf("unknown format %q", r.Format)
+	}
+}
+
+// execute runs the template and ends the output with a newline so that each value is on its own line
+func (r *Renderer) execute(w io.Writer, v any) error {
+	var sb strings.Builder
+	if err := r.tmpl.Execute(&sb, v); err != nil {
+		return err
+	}
+	out := sb.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+func writeJson(w io.Writer, v any, indent string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", indent)
+	return enc.Encode(v)
+}
+
+// writeYaml writes the value with the same field names and order as in json
+func writeYaml(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err = yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err = enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle resets the flow style of the json so that it is written as a regular yaml document
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+// day is the date of the event, the raw date when it has not been parsed
+func day(e models.Event) string {
+	if e.StartsAt != nil {
+		return e.StartsAt.Format("2006-01-02")
+	}
+	return e.Date
+}
+
+func stock(e models.Event) string {
+	if e.InStock {
+		return "in stock"
+	}
+	return "sold out"
+}
+
+func eventTable(w io.Writer, events []models.Event) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tDATE\tHEADLINE\tVENUE\tSTOCK")
+	for _, e := range events {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.ID(), day(e), e.Headline, e.Venue, stock(e))
+	}
+	return tw.Flush()
+}
+
+func detailTable(w io.Writer, d Detailed) error {
+	e := d.Event
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(name string, value string) {
+		if len(value) > 0 {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", name, value)
+		}
+	}
+	row("ID", e.ID())
+	row("Headline", e.Headline)
+	row("Date", strings.TrimSpace(e.Weekday+" "+e.Date))
+	row("Venue", e.Venue)
+	row("Stock", stock(e))
+	row("Link", e.EventLink)
+	row("Tickets", e.StoreLink)
+	for _, bp := range e.BulletPoints {
+		row("", bp)
+	}
+	if ed := d.Details; ed != nil {
+		if ed.DoorsOpen != nil {
+			row("Doors", ed.DoorsOpen.Format("15:04"))
+		}
+		for _, pt := range ed.PlayTimes {
+			row("Play time", pt)
+		}
+		for _, t := range ed.Tickets.Tickets {
+			row("Price", strings.TrimSpace(t.Description+" "+price(t.ParsedPrice, t.Price)))
+		}
+		if len(ed.DoorPrice) > 0 {
+			row("Door price", price(ed.ParsedDoorPrice, ed.DoorPrice))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if d.Details != nil && len(d.Details.Description) > 0 {
+		_, err := fmt.Fprintf(w, "\n%s\n", strings.Join(d.Details.Description, "\n\n"))
+		return err
+	}
+	return nil
+}
+
+// price formats the parsed price, the original text when it could not be parsed
+func price(p *models.Price, raw string) string {
+	if p == nil {
+		return raw
+	}
+	return p.String()
+}
+
+var csvHeader = []string{"id", "date", "starts_at", "headline", "venue", "in_stock", "event_link", "store_link", "bullet_points"}
+
+func eventCsv(w io.Writer, events []models.Event) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range events {
+		startsAt := ""
+		if e.StartsAt != nil {
+			startsAt = e.StartsAt.Format(time.RFC3339)
+		}
+		err := cw.Write([]string{
+			e.ID(), e.Date, startsAt, e.Headline, e.Venue, strconv.FormatBool(e.InStock),
+			e.EventLink, e.StoreLink, strings.Join(e.BulletPoints, " | "),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
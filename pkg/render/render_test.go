@@ -0,0 +1,139 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEvents() []models.Event {
+	startsAt := time.Date(2024, 10, 18, 0, 0, 0, 0, time.UTC)
+	return []models.Event{
+		{Id: "one", Headline: "Band One", Date: "18.10.", StartsAt: &startsAt, InStock: true, Venue: "Lutakko",
+			BulletPoints: []string{"Liput 25€", "K-18"}},
+		{Id: "two", Headline: "Band, \"Two\"", Date: "2.11."},
+	}
+}
+
+func render(t *testing.T, f Format, tmpl string) string {
+	r, err := New(f, tmpl)
+	if err != nil {
+		t.Fatalf("err creating renderer: %s", err)
+	}
+	var buf bytes.Buffer
+	if err = r.Events(&buf, testEvents()); err != nil {
+		t.Fatalf("err rendering %s: %s", f, err)
+	}
+	return buf.String()
+}
+
+func TestEventsTable(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(render(t, Table, "")), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") {
+		t.Fatalf("unexpected table:\n%s", strings.Join(lines, "\n"))
+	}
+	if strings.Index(lines[0], "HEADLINE") != strings.Index(lines[1], "Band One") {
+		t.Errorf("columns are not aligned:\n%s", strings.Join(lines, "\n"))
+	}
+	if !strings.Contains(lines[1], "2024-10-18") || !strings.Contains(lines[2], "2.11.") {
+		t.Errorf("expected parsed and raw dates:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestEventsJson(t *testing.T) {
+	var events []models.Event
+	if err := json.Unmarshal([]byte(render(t, Json, "")), &events); err != nil || len(events) != 2 {
+		t.Errorf("expected 2 events, got %v with err %v", events, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(render(t, NDJson, "")), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a line for each event, got %d", len(lines))
+	}
+	var e models.Event
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil || e.Id != "two" {
+		t.Errorf("expected event two, got %v with err %v", e, err)
+	}
+}
+
+func TestEventsCsv(t *testing.T) {
+	records, err := csv.NewReader(strings.NewReader(render(t, Csv, ""))).ReadAll()
+	if err != nil {
+		t.Fatalf("err reading csv: %s", err)
+	}
+	if len(records) != 3 || records[0][0] != "id" {
+		t.Fatalf("unexpected csv: %v", records)
+	}
+	if records[1][8] != "Liput 25€ | K-18" || records[2][3] != "Band, \"Two\"" {
+		t.Errorf("unexpected rows: %v", records[1:])
+	}
+}
+
+func TestEventsYaml(t *testing.T) {
+	out := render(t, Yaml, "")
+	var events []map[string]any
+	if err := yaml.Unmarshal([]byte(out), &events); err != nil || len(events) != 2 {
+		t.Fatalf("expected 2 events, got %v with err %v", events, err)
+	}
+	if events[0]["event_link"] == nil || strings.Contains(out, "{") {
+		t.Errorf("expected block yaml with json field names:\n%s", out)
+	}
+}
+
+func TestEventsTemplate(t *testing.T) {
+	out := render(t, Template, `{{.Id}}: {{join .BulletPoints ", "}} {{date "02.01." .StartsAt}}`)
+	want := "one: Liput 25€, K-18 18.10.\ntwo:  \n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	if _, err := New(Template, ""); err == nil {
+		t.Errorf("expected err without a template")
+	}
+	if _, err := New(Template, "{{.Id"); err == nil {
+		t.Errorf("expected err for an invalid template")
+	}
+}
+
+func TestEvent(t *testing.T) {
+	price := models.Price{Cents: 2500, Currency: "EUR", Text: "25,00 €"}
+	d := Detailed{
+		Event: testEvents()[0],
+		Details: &models.EventDetails{
+			EventID:     "one",
+			Description: []string{"First paragraph", "Second paragraph"},
+			PlayTimes:   []string{"Band One 21:00"},
+			Tickets:     models.EventTickets{Tickets: []models.Ticket{{Description: "Ennakko", Price: "25,00 €", ParsedPrice: &price}}},
+		},
+	}
+	r, _ := New(Table, "")
+	var buf bytes.Buffer
+	if err := r.Event(&buf, d); err != nil {
+		t.Fatalf("err rendering event: %s", err)
+	}
+	for _, want := range []string{"Headline", "Band One", "Play time", "Ennakko 25 €", "First paragraph\n\nSecond paragraph"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, buf.String())
+		}
+	}
+
+	r, _ = New(Template, "{{.Event.Headline}} {{len .Details.PlayTimes}}")
+	buf.Reset()
+	if err := r.Event(&buf, d); err != nil || buf.String() != "Band One 1\n" {
+		t.Errorf("unexpected template output %q with err %v", buf.String(), err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("YML"); err != nil || f != Yaml {
+		t.Errorf("expected yaml, got %s %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("expected err for unknown format")
+	}
+}
@@ -0,0 +1,396 @@
+// Package server exposes a provider as a read-only JSON HTTP API
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/provider"
+	"github.com/johannessarpola/lutakkols/pkg/export"
+	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/johannessarpola/lutakkols/pkg/filter"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OriginHeader tells where the provider served the response from
+const OriginHeader = "X-Lutakkols-Origin"
+
+// Server serves the events of the provider
+type Server struct {
+	p         provider.Provider
+	mux       *http.ServeMux
+	collected collected
+}
+
+// maxCollected is the amount of filters whose items are cached, the cache is emptied when it is full
+const maxCollected = 32
+
+// collected caches the events with their details for the calendar and the feeds, tag is the ETag of the listing
+// the items were collected from and items are by the key of the filter
+type collected struct {
+	mtx   sync.Mutex
+	tag   string
+	items map[string][]export.Item
+}
+
+// New creates the server for the provider
+func New(p provider.Provider) *Server {
+	s := &Server{p: p, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /health", s.health)
+	s.mux.HandleFunc("GET /events", s.events)
+	s.mux.HandleFunc("GET /events/{id}", s.event)
+	s.mux.HandleFunc("GET /events/{id}/details", s.details)
+	s.mux.HandleFunc("GET /events/{id}/ascii", s.ascii)
+	return s
+}
+
+// WithCalendar adds /events.ics which has the events filtered as /events as an iCalendar feed
+func (s *Server) WithCalendar() *Server {
+	s.mux.HandleFunc("GET /events.ics", s.calendar)
+	return s
+}
+
+// WithFeed adds /events.atom and /events.rss which have the events filtered as /events as feeds, link is the site
+// the feeds refer to
+func (s *Server) WithFeed(link string) *Server {
+	s.mux.HandleFunc("GET /events.atom", s.feed(export.Atom, link))
+	s.mux.HandleFunc("GET /events.rss", s.feed(export.RSS, link))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves until the ctx is done, after which the pending requests have shutdownTimeout to finish
+func ListenAndServe(ctx context.Context, addr string, h http.Handler, shutdownTimeout time.Duration) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		logger.Log.Infof("serving on %s", addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Log.Infof("shutting down the server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) health(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := s.p.GetEvents(r.Context())
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+
+	rs := *events
+	rs.Events = f.Apply(events.Events)
+	if rs.Events == nil {
+		rs.Events = []models.Event{}
+	}
+	s.respond(w, r, rs.Origin, etag(latest(events), r.URL.RawQuery), rs)
+}
+
+func (s *Server) calendar(w http.ResponseWriter, r *http.Request) {
+	f, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := s.p.GetEvents(r.Context())
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	tag := etag(latest(events), "ics?"+r.URL.RawQuery)
+	if !s.fresh(w, r, events.Origin, tag) {
+		return
+	}
+
+	items, err := s.collect(r, events, f)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if err = export.WriteICS(w, items, time.Now()); err != nil {
+		logger.Log.Warnf("could not write calendar: %v", err)
+	}
+}
+
+func (s *Server) feed(format export.FeedFormat, link string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseFilter(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		events, err := s.p.GetEvents(r.Context())
+		if err != nil {
+			writeProviderError(w, err)
+			return
+		}
+		tag := etag(latest(events), string(format)+"?"+r.URL.RawQuery)
+		if !s.fresh(w, r, events.Origin, tag) {
+			return
+		}
+
+		items, err := s.collect(r, events, f)
+		if err != nil {
+			writeProviderError(w, err)
+			return
+		}
+		info := export.FeedInfo{
+			Title:   export.CalendarName,
+			Link:    link,
+			Self:    selfURL(r),
+			Updated: latest(events),
+		}
+		w.Header().Set("Content-Type", format.ContentType())
+		if err = export.WriteFeed(w, format, info, items); err != nil {
+			logger.Log.Warnf("could not write feed: %v", err)
+		}
+	}
+}
+
+// collect returns the filtered events with their details, the details are fetched only once for each filter while
+// the listing is unchanged
+func (s *Server) collect(r *http.Request, events *models.Events, f filter.Filter) ([]export.Item, error) {
+	tag := etag(latest(events), "")
+	key := f.Key()
+	if len(tag) > 0 {
+		s.collected.mtx.Lock()
+		items, ok := s.collected.items[key]
+		ok = ok && s.collected.tag == tag
+		s.collected.mtx.Unlock()
+		if ok {
+			return items, nil
+		}
+	}
+
+	items, err := export.Collect(r.Context(), s.p, f.Apply(events.Events))
+	if err != nil || len(tag) == 0 {
+		return items, err
+	}
+
+	s.collected.mtx.Lock()
+	defer s.collected.mtx.Unlock()
+	if s.collected.tag != tag || len(s.collected.items) >= maxCollected {
+		s.collected.tag = tag
+		s.collected.items = map[string][]export.Item{}
+	}
+	s.collected.items[key] = items
+	return items, nil
+}
+
+func (s *Server) event(w http.ResponseWriter, r *http.Request) {
+	evt, events, ok := s.find(w, r)
+	if !ok {
+		return
+	}
+	updatedAt := evt.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = events.UpdatedAt
+	}
+	s.respond(w, r, events.Origin, etag(updatedAt, ""), evt)
+}
+
+func (s *Server) details(w http.ResponseWriter, r *http.Request) {
+	evt, _, ok := s.find(w, r)
+	if !ok {
+		return
+	}
+	ed, err := s.p.GetDetails(r.Context(), evt.ID(), evt.EventURL())
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	s.respond(w, r, ed.Origin, etag(ed.UpdatedAt, ""), ed)
+}
+
+func (s *Server) ascii(w http.ResponseWriter, r *http.Request) {
+	evt, _, ok := s.find(w, r)
+	if !ok {
+		return
+	}
+	ed, err := s.p.GetDetails(r.Context(), evt.ID(), evt.EventURL())
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	ea, err := s.p.GetAscii(r.Context(), evt.ID(), ed.ImageURL())
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	s.respond(w, r, ea.Origin, etag(ea.UpdatedAt, ""), ea)
+}
+
+// find looks up the event of the path, the error response has been written when it is not found
+func (s *Server) find(w http.ResponseWriter, r *http.Request) (models.Event, *models.Events, bool) {
+	id := r.PathValue("id")
+	events, err := s.p.GetEvents(r.Context())
+	if err != nil {
+		writeProviderError(w, err)
+		return models.Event{}, nil, false
+	}
+	for _, evt := range events.Events {
+		if evt.ID() == id {
+			return evt, events, true
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("no event with id %s", id))
+	return models.Event{}, nil, false
+}
+
+// respond writes the value as JSON unless the client already has it
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, origin models.Origin, tag string, v any) {
+	if s.fresh(w, r, origin, tag) {
+		writeJson(w, http.StatusOK, v)
+	}
+}
+
+// fresh sets the headers and tells if the response should be written, Not Modified has been written when the client
+// already has it according to the If-None-Match
+func (s *Server) fresh(w http.ResponseWriter, r *http.Request, origin models.Origin, tag string) bool {
+	if len(origin) > 0 {
+		w.Header().Set(OriginHeader, string(origin))
+	}
+	if len(tag) > 0 {
+		w.Header().Set("ETag", tag)
+		if matches(r.Header.Get("If-None-Match"), tag) {
+			w.WriteHeader(http.StatusNotModified)
+			return false
+		}
+	}
+	return true
+}
+
+// parseFilter reads the filter from the query: from and to as days, in_stock as boolean and q as text
+func parseFilter(r *http.Request) (filter.Filter, error) {
+	var f filter.Filter
+	q := r.URL.Query()
+	var err error
+	if from := q.Get("from"); len(from) > 0 {
+		if f.From, err = filter.Day(from); err != nil {
+			return f, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if to := q.Get("to"); len(to) > 0 {
+		if f.To, err = filter.Day(to); err != nil {
+			return f, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if inStock := q.Get("in_stock"); len(inStock) > 0 {
+		b, err := strconv.ParseBool(inStock)
+		if err != nil {
+			return f, fmt.Errorf("invalid in_stock: %w", err)
+		}
+		f.InStock = &b
+	}
+	f.Text = strings.TrimSpace(q.Get("q"))
+	return f, nil
+}
+
+// selfURL is the url of the request as the client sees it
+func selfURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.RequestURI())
+}
+
+// latest returns the newest update of the listing or any of the events
+func latest(events *models.Events) time.Time {
+	rs := events.UpdatedAt
+	for _, evt := range events.Events {
+		if evt.UpdatedAt.After(rs) {
+			rs = evt.UpdatedAt
+		}
+	}
+	return rs
+}
+
+// etag is a weak tag from the update time and the query, empty when the update time is not known
+func etag(updatedAt time.Time, query string) string {
+	if updatedAt.IsZero() {
+		return ""
+	}
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%d?%s", updatedAt.UnixNano(), query)
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+func matches(ifNoneMatch string, tag string) bool {
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Log.Warnf("could not write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJson(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeProviderError tells a temporary outage of the source apart from other failures
+func writeProviderError(w http.ResponseWriter, err error) {
+	logger.Log.Errorf("provider failed: %v", err)
+	switch {
+	case errors.Is(err, context.Canceled):
+		// the client is gone
+	case errors.Is(err, context.DeadlineExceeded), fetch.IsTransient(err):
+		writeError(w, http.StatusServiceUnavailable, err)
+	default:
+		writeError(w, http.StatusBadGateway, err)
+	}
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/api/options"
+	"github.com/johannessarpola/lutakkols/pkg/api/provider"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testData = "../api/internal/offline/test_data/"
+
+func setupServer(t *testing.T) *httptest.Server {
+	p, err := provider.New(&provider.Config{
+		EventSourceFsPath:  testData + "events_test.json",
+		EventDetailsFsPath: testData + "event_details_test.json",
+		EventAsciiFsPath:   testData + "event_ascii_test.json",
+		AsciiGen:           func(_ string, _ string) string { return "placeholder" },
+	}, options.UseOffline)
+	if err != nil {
+		t.Fatalf("could not create provider: %s", err)
+	}
+	srv := httptest.NewServer(New(p).WithCalendar().WithFeed("https://example.com"))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, url string, header http.Header, v any) *http.Response {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err requesting %s: %s", url, err)
+	}
+	defer res.Body.Close()
+	if v != nil && res.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			t.Fatalf("err decoding %s: %s", url, err)
+		}
+	}
+	return res
+}
+
+func TestHealth(t *testing.T) {
+	srv := setupServer(t)
+
+	var rs map[string]string
+	res := get(t, srv.URL+"/health", nil, &rs)
+	if res.StatusCode != http.StatusOK || rs["status"] != "ok" {
+		t.Errorf("expected healthy, got %d %v", res.StatusCode, rs)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	srv := setupServer(t)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"event1", "event2"}},
+		{"?in_stock=true", []string{"event1"}},
+		{"?in_stock=false", []string{"event2"}},
+		{"?from=2024-05-31", []string{"event2"}},
+		{"?from=2024-05-01&to=2024-05-31", []string{"event1"}},
+		{"?q=concert", []string{"event1"}},
+		{"?q=nothing", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var events models.Events
+			res := get(t, srv.URL+"/events"+tt.query, nil, &events)
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("expected 200, got %d", res.StatusCode)
+			}
+			if res.Header.Get(OriginHeader) != string(models.OriginOffline) {
+				t.Errorf("expected offline origin, got %s", res.Header.Get(OriginHeader))
+			}
+			if len(events.Events) != len(tt.want) {
+				t.Fatalf("expected %v, got %d events", tt.want, len(events.Events))
+			}
+			for i, e := range events.Events {
+				if e.Id != tt.want[i] {
+					t.Errorf("expected %s, got %s", tt.want[i], e.Id)
+				}
+			}
+		})
+	}
+
+	for _, query := range []string{"?in_stock=maybe", "?from=someday"} {
+		if res := get(t, srv.URL+"/events"+query, nil, nil); res.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, res.StatusCode)
+		}
+	}
+}
+
+func TestEventsETag(t *testing.T) {
+	srv := setupServer(t)
+
+	res := get(t, srv.URL+"/events", nil, nil)
+	tag := res.Header.Get("ETag")
+	if len(tag) == 0 {
+		t.Fatalf("expected an ETag")
+	}
+
+	res = get(t, srv.URL+"/events", http.Header{"If-None-Match": []string{tag}}, nil)
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 with matching ETag, got %d", res.StatusCode)
+	}
+
+	res = get(t, srv.URL+"/events?q=concert", http.Header{"If-None-Match": []string{tag}}, nil)
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") == tag {
+		t.Errorf("expected a different representation for the filtered events, got %d", res.StatusCode)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	srv := setupServer(t)
+
+	var evt models.Event
+	res := get(t, srv.URL+"/events/event2", nil, &evt)
+	if res.StatusCode != http.StatusOK || evt.Headline != "Art Exhibition" {
+		t.Errorf("expected event2, got %d %v", res.StatusCode, evt)
+	}
+
+	var ed models.EventDetails
+	res = get(t, srv.URL+"/events/event1/details", nil, &ed)
+	if res.StatusCode != http.StatusOK || ed.ImageLink != "https://example.com/images/event1_large.jpg" {
+		t.Errorf("expected details of event1, got %d %v", res.StatusCode, ed)
+	}
+
+	var ea models.EventAscii
+	res = get(t, srv.URL+"/events/event1/ascii", nil, &ea)
+	if res.StatusCode != http.StatusOK || ea.Ascii != "#####" {
+		t.Errorf("expected ascii of event1, got %d %v", res.StatusCode, ea)
+	}
+
+	for _, p := range []string{"/events/missing", "/events/missing/details", "/events/missing/ascii"} {
+		if res := get(t, srv.URL+p, nil, nil); res.StatusCode != http.StatusNotFound {
+			t.Errorf("expected 404 for %s, got %d", p, res.StatusCode)
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	srv := setupServer(t)
+
+	res, err := http.Get(srv.URL + "/events.ics?in_stock=true")
+	if err != nil {
+		t.Fatalf("err requesting calendar: %s", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/calendar") {
+		t.Fatalf("expected a calendar, got %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), "UID:event1@lutakkols") || strings.Contains(string(body), "event2") {
+		t.Errorf("expected only event1 in the calendar:\n%s", body)
+	}
+
+	res = get(t, srv.URL+"/events.ics?in_stock=true", http.Header{"If-None-Match": []string{res.Header.Get("ETag")}}, nil)
+	if res.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 with matching ETag, got %d", res.StatusCode)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	srv := setupServer(t)
+
+	for _, p := range []string{"/events.atom", "/events.rss"} {
+		res, err := http.Get(srv.URL + p + "?q=art")
+		if err != nil {
+			t.Fatalf("err requesting %s: %s", p, err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK || !strings.Contains(res.Header.Get("Content-Type"), "xml") {
+			t.Fatalf("expected a feed from %s, got %d %s", p, res.StatusCode, res.Header.Get("Content-Type"))
+		}
+		if !strings.Contains(string(body), "urn:lutakkols:event:event2") || strings.Contains(string(body), "event1") {
+			t.Errorf("expected only event2 in %s:\n%s", p, body)
+		}
+		if !strings.Contains(string(body), "An inspiring art exhibition") {
+			t.Errorf("expected the description in %s:\n%s", p, body)
+		}
+	}
+}
+
+// countingProvider counts the details fetched from the provider
+type countingProvider struct {
+	provider.Provider
+	details atomic.Int32
+}
+
+func (c *countingProvider) GetDetails(ctx context.Context, eventID string, eventURL string, opts ...options.ProviderOption) (models.EventDetails, error) {
+	c.details.Add(1)
+	return c.Provider.GetDetails(ctx, eventID, eventURL, opts...)
+}
+
+func TestCollectedCache(t *testing.T) {
+	p, err := provider.New(&provider.Config{
+		EventSourceFsPath:  testData + "events_test.json",
+		EventDetailsFsPath: testData + "event_details_test.json",
+		EventAsciiFsPath:   testData + "event_ascii_test.json",
+		AsciiGen:           func(_ string, _ string) string { return "placeholder" },
+	}, options.UseOffline)
+	if err != nil {
+		t.Fatalf("could not create provider: %s", err)
+	}
+	cp := &countingProvider{Provider: p}
+	s := New(cp).WithCalendar().WithFeed("https://example.com")
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	for _, path := range []string{"/events.ics", "/events.ics", "/events.rss", "/events.atom"} {
+		if res := get(t, srv.URL+path, nil, nil); res.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200 from %s, got %d", path, res.StatusCode)
+		}
+	}
+	events, _ := p.GetEvents(context.Background())
+	if n := cp.details.Load(); int(n) != len(events.Events) {
+		t.Errorf("expected the details of %d events to be fetched once, got %d fetches", len(events.Events), n)
+	}
+
+	get(t, srv.URL+"/events.ics?in_stock=true", nil, nil)
+	if n := cp.details.Load(); int(n) != len(events.Events)+1 {
+		t.Errorf("expected the details to be fetched for another filter, got %d fetches", n)
+	}
+
+	// the same filter written differently uses the cached items
+	get(t, srv.URL+"/events.rss?in_stock=1&utm_source=feed", nil, nil)
+	if n := cp.details.Load(); int(n) != len(events.Events)+1 {
+		t.Errorf("expected the cached items for the same filter, got %d fetches", n)
+	}
+
+	for i := range maxCollected + 1 {
+		get(t, fmt.Sprintf("%s/events.ics?q=nomatch%d", srv.URL, i), nil, nil)
+	}
+	s.collected.mtx.Lock()
+	defer s.collected.mtx.Unlock()
+	if n := len(s.collected.items); n > maxCollected {
+		t.Errorf("expected at most %d cached filters, got %d", maxCollected, n)
+	}
+}
@@ -0,0 +1,305 @@
+// Package site renders the synced data into a static HTML site with an index, a page for each event and a month
+// calendar. The links are relative so that the site works from a file share and the output only changes when the
+// data does.
+package site
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/filter"
+	"github.com/johannessarpola/lutakkols/pkg/snapshot"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+//go:embed templates
+var embedded embed.FS
+
+const (
+	eventsDir   = "events"
+	calendarDir = "calendar"
+	styleFile   = "style.css"
+	// DefaultTitle is the title of the site unless given
+	DefaultTitle = "Lutakko gigs"
+)
+
+var (
+	unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+	// ansiEscapes are the terminal colors of the synced ascii art which would show up as garbage in the pages
+	ansiEscapes = regexp.MustCompile("\x1b\\[[0-9;?]*[ -/]*[@-~]")
+)
+
+// Config configures the build, Templates is a directory with templates overriding the embedded ones by name
+type Config struct {
+	OutputDir string
+	Templates string
+	Title     string
+}
+
+// Event is an event as shown on the pages, Page is relative to the root of the site
+type Event struct {
+	models.Event
+	Page    string
+	Date    string
+	Details *models.EventDetails
+	Ascii   string
+	day     *time.Time
+}
+
+// Day is a cell of the calendar
+type Day struct {
+	Day     int
+	InMonth bool
+	Events  []Event
+}
+
+// page is the data for every template, Root is the relative path from the page to the root of the site
+type page struct {
+	Root      string
+	Title     string
+	PageTitle string
+	Calendar  string
+	Events    []Event
+	Event     Event
+	Month     string
+	Prev      string
+	Next      string
+	Weeks     [][]Day
+}
+
+// Build renders the snapshot into the output directory, pages of events which are no longer in the snapshot
+// are removed
+func Build(snap snapshot.Snapshot, conf Config) error {
+	if len(conf.Title) == 0 {
+		conf.Title = DefaultTitle
+	}
+	templates := overlay(conf.Templates)
+	events := pageEvents(snap)
+
+	w := &siteWriter{dir: conf.OutputDir, written: map[string]bool{}}
+	months := monthPages(events)
+	calendar := ""
+	if len(months) > 0 {
+		calendar = monthPage(months[0])
+	}
+
+	var listed []Event
+	for _, e := range events {
+		if !e.Archived {
+			listed = append(listed, e)
+		}
+	}
+	index := page{Root: "", Title: conf.Title, PageTitle: conf.Title, Calendar: calendar, Events: listed}
+	if err := w.render(templates, "index.html", "index.html", index); err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		p := page{Root: "../", Title: conf.Title, PageTitle: e.Headline + " | " + conf.Title, Calendar: calendar, Event: e}
+		if err := w.render(templates, "event.html", e.Page, p); err != nil {
+			return err
+		}
+	}
+
+	for i, m := range months {
+		p := page{
+			Root:      "../",
+			Title:     conf.Title,
+			PageTitle: m.Format("January 2006") + " | " + conf.Title,
+			Calendar:  calendar,
+			Month:     m.Format("January 2006"),
+			Weeks:     weeks(m, listed),
+		}
+		if i > 0 {
+			p.Prev = path.Base(monthPage(months[i-1]))
+		}
+		if i < len(months)-1 {
+			p.Next = path.Base(monthPage(months[i+1]))
+		}
+		if err := w.render(templates, "calendar.html", monthPage(m), p); err != nil {
+			return err
+		}
+	}
+
+	style, err := fs.ReadFile(templates, styleFile)
+	if err != nil {
+		return err
+	}
+	if err = w.write(styleFile, style); err != nil {
+		return err
+	}
+	return w.removeStale(eventsDir, calendarDir)
+}
+
+// pageEvents sorts the events by date with the undated last, archived events keep their pages
+func pageEvents(snap snapshot.Snapshot) []Event {
+	details := map[string]*models.EventDetails{}
+	for i := range snap.Details {
+		details[snap.Details[i].ID()] = &snap.Details[i]
+	}
+	ascii := map[string]string{}
+	for _, a := range snap.Ascii {
+		ascii[a.ID()] = ansiEscapes.ReplaceAllString(a.Ascii, "")
+	}
+
+	events := make([]Event, 0, len(snap.Events))
+	for _, e := range snap.Events {
+		pe := Event{
+			Event:   e,
+			Page:    path.Join(eventsDir, unsafeChars.ReplaceAllString(e.ID(), "-")+".html"),
+			Date:    strings.TrimSpace(e.Weekday + " " + e.Date),
+			Details: details[e.ID()],
+			Ascii:   ascii[e.ID()],
+		}
+		if d, ok := filter.StartDay(e); ok {
+			pe.day = &d
+		}
+		events = append(events, pe)
+	}
+	slices.SortStableFunc(events, func(a, b Event) int {
+		switch {
+		case a.day == nil && b.day == nil:
+			return 0
+		case a.day == nil:
+			return 1
+		case b.day == nil:
+			return -1
+		default:
+			return a.day.Compare(*b.day)
+		}
+	})
+	return events
+}
+
+// monthPages returns the first days of the months which have listed events
+func monthPages(events []Event) []time.Time {
+	var months []time.Time
+	for _, e := range events {
+		if e.day == nil || e.Archived {
+			continue
+		}
+		m := time.Date(e.day.Year(), e.day.Month(), 1, 0, 0, 0, 0, e.day.Location())
+		if len(months) == 0 || !months[len(months)-1].Equal(m) {
+			months = append(months, m)
+		}
+	}
+	return months
+}
+
+func monthPage(month time.Time) string {
+	return path.Join(calendarDir, month.Format("2006-01")+".html")
+}
+
+// weeks lays out the month into weeks starting on Monday
+func weeks(month time.Time, events []Event) [][]Day {
+	byDay := map[int][]Event{}
+	for _, e := range events {
+		if e.day != nil && e.day.Year() == month.Year() && e.day.Month() == month.Month() {
+			byDay[e.day.Day()] = append(byDay[e.day.Day()], e)
+		}
+	}
+
+	offset := (int(month.Weekday()) + 6) % 7
+	start := month.AddDate(0, 0, -offset)
+	var rs [][]Day
+	for d := start; d.Before(month.AddDate(0, 1, 0)); {
+		week := make([]Day, 0, 7)
+		for i := 0; i < 7; i++ {
+			inMonth := d.Month() == month.Month()
+			day := Day{Day: d.Day(), InMonth: inMonth}
+			if inMonth {
+				day.Events = byDay[d.Day()]
+			}
+			week = append(week, day)
+			d = d.AddDate(0, 0, 1)
+		}
+		rs = append(rs, week)
+	}
+	return rs
+}
+
+// overlay returns the templates where the files in dir replace the embedded ones
+func overlay(dir string) fs.FS {
+	base, _ := fs.Sub(embedded, "templates")
+	if len(dir) == 0 {
+		return base
+	}
+	return overlayFS{top: os.DirFS(dir), base: base}
+}
+
+type overlayFS struct {
+	top  fs.FS
+	base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.base.Open(name)
+	}
+	return f, err
+}
+
+// siteWriter writes the files of the site and keeps track of them so that stale pages can be removed
+type siteWriter struct {
+	dir     string
+	written map[string]bool
+}
+
+func (w *siteWriter) render(templates fs.FS, name string, target string, data page) error {
+	t, err := template.ParseFS(templates, "layout.html", name)
+	if err != nil {
+		return fmt.Errorf("could not parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err = t.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return fmt.Errorf("could not render %s: %w", target, err)
+	}
+	return w.write(target, buf.Bytes())
+}
+
+// write writes the file unless it already has the same content
+func (w *siteWriter) write(target string, content []byte) error {
+	w.written[target] = true
+	fp := filepath.Join(w.dir, filepath.FromSlash(target))
+	if existing, err := os.ReadFile(fp); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fp, content, 0644)
+}
+
+// removeStale removes the html files in the directories which were not written by this build
+func (w *siteWriter) removeStale(dirs ...string) error {
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(filepath.Join(w.dir, dir))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			target := path.Join(dir, e.Name())
+			if e.IsDir() || path.Ext(e.Name()) != ".html" || w.written[target] {
+				continue
+			}
+			if err = os.Remove(filepath.Join(w.dir, filepath.FromSlash(target))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
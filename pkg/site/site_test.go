@@ -0,0 +1,129 @@
+package site
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/snapshot"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testData = "../api/internal/offline/test_data/"
+
+func loadTestSnapshot(t *testing.T) snapshot.Snapshot {
+	snap, err := snapshot.Load(snapshot.Paths{
+		Events:  testData + "events_test.json",
+		Details: testData + "event_details_test.json",
+		Ascii:   testData + "event_ascii_test.json",
+	})
+	if err != nil {
+		t.Fatalf("could not load snapshot: %s", err)
+	}
+	return snap
+}
+
+func readFile(t *testing.T, dir string, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("could not read %s: %s", name, err)
+	}
+	return string(b)
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	snap := loadTestSnapshot(t)
+	if err := Build(snap, Config{OutputDir: dir}); err != nil {
+		t.Fatalf("err building site: %s", err)
+	}
+
+	index := readFile(t, dir, "index.html")
+	for _, want := range []string{`href="style.css"`, `href="events/event1.html"`, `href="calendar/2024-05.html"`, "Music Concert"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("expected %q in index:\n%s", want, index)
+		}
+	}
+
+	event := readFile(t, dir, "events/event1.html")
+	for _, want := range []string{`href="../style.css"`, `href="../index.html"`, "#####", "A spectacular music concert"} {
+		if !strings.Contains(event, want) {
+			t.Errorf("expected %q in event page:\n%s", want, event)
+		}
+	}
+
+	may := readFile(t, dir, "calendar/2024-05.html")
+	if !strings.Contains(may, `href="../events/event1.html"`) || !strings.Contains(may, `href="2024-06.html"`) {
+		t.Errorf("expected the event and the next month in calendar:\n%s", may)
+	}
+	june := readFile(t, dir, "calendar/2024-06.html")
+	if !strings.Contains(june, `href="2024-05.html"`) || strings.Contains(june, "event1.html") {
+		t.Errorf("expected only the previous month link and event2 in calendar:\n%s", june)
+	}
+	if strings.Count(may, "<tr>") != 5+1 {
+		t.Errorf("expected 5 weeks in May 2024")
+	}
+}
+
+func TestBuildStripsColors(t *testing.T) {
+	dir := t.TempDir()
+	snap := loadTestSnapshot(t)
+	colored, err := os.ReadFile("../fetch/test_data/golden/event_ascii.txt")
+	if err != nil {
+		t.Fatalf("could not read colored ascii: %s", err)
+	}
+	snap.Ascii[0].Ascii = string(colored)
+	if err = Build(snap, Config{OutputDir: dir}); err != nil {
+		t.Fatalf("err building site: %s", err)
+	}
+
+	event := readFile(t, dir, "events/"+snap.Ascii[0].ID()+".html")
+	if strings.Contains(event, "\x1b") || strings.Contains(event, "[38;5;") {
+		t.Errorf("expected no ansi escapes in the event page")
+	}
+	if !strings.Contains(event, `<pre class="ascii">`) {
+		t.Errorf("expected the ascii art in the event page")
+	}
+}
+
+func TestBuildIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	snap := loadTestSnapshot(t)
+	if err := Build(snap, Config{OutputDir: dir}); err != nil {
+		t.Fatalf("err building site: %s", err)
+	}
+	first := readFile(t, dir, "index.html") + readFile(t, dir, "events/event2.html")
+
+	// a stale page is removed when the event is gone
+	snap.Events = snap.Events[:1]
+	if err := Build(snap, Config{OutputDir: dir}); err != nil {
+		t.Fatalf("err building site: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events/event2.html")); !os.IsNotExist(err) {
+		t.Errorf("expected the page of the removed event to be removed")
+	}
+
+	snap = loadTestSnapshot(t)
+	if err := Build(snap, Config{OutputDir: dir}); err != nil {
+		t.Fatalf("err building site: %s", err)
+	}
+	if second := readFile(t, dir, "index.html") + readFile(t, dir, "events/event2.html"); first != second {
+		t.Errorf("expected the same output for the same data")
+	}
+}
+
+func TestBuildWithTemplateOverride(t *testing.T) {
+	templates := t.TempDir()
+	err := os.WriteFile(filepath.Join(templates, "index.html"), []byte(`{{define "content"}}custom {{len .Events}}{{end}}`), 0644)
+	if err != nil {
+		t.Fatalf("could not write template: %s", err)
+	}
+
+	dir := t.TempDir()
+	if err = Build(loadTestSnapshot(t), Config{OutputDir: dir, Templates: templates, Title: "Gigs"}); err != nil {
+		t.Fatalf("err building site: %s", err)
+	}
+	index := readFile(t, dir, "index.html")
+	if !strings.Contains(index, "custom 2") || !strings.Contains(index, "<title>Gigs</title>") {
+		t.Errorf("expected the overridden index within the embedded layout:\n%s", index)
+	}
+}
@@ -0,0 +1,220 @@
+// Package snapshot contains the synced data on disk and the merging of a new sync into the previous one so that
+// only new or changed events have to be fetched and a partial sync never loses data
+package snapshot
+
+import (
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/writer"
+	"os"
+	"slices"
+)
+
+// Paths are the files of the snapshot, empty paths are not read or written
+type Paths struct {
+	Events  string
+	Details string
+	Ascii   string
+}
+
+// Snapshot is the synced data
+type Snapshot struct {
+	Events  []models.Event
+	Details []models.EventDetails
+	Ascii   []models.EventAscii
+}
+
+// Load reads the snapshot from the paths, missing files are treated as empty
+func Load(p Paths) (Snapshot, error) {
+	var s Snapshot
+	if err := readJson(p.Events, &s.Events); err != nil {
+		return s, err
+	}
+	if err := readJson(p.Details, &s.Details); err != nil {
+		return s, err
+	}
+	if err := readJson(p.Ascii, &s.Ascii); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+func readJson(fp string, v any) error {
+	if len(fp) == 0 {
+		return nil
+	}
+	if err := writer.ReadJson(fp, v); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Write writes the snapshot into temporary files first and then renames them over the paths so that a failing
+// write leaves the previous snapshot intact
+func (s Snapshot) Write(p Paths) error {
+	type file struct {
+		path string
+		data any
+	}
+	var files []file
+	for _, f := range []file{{p.Events, s.Events}, {p.Details, s.Details}, {p.Ascii, s.Ascii}} {
+		if len(f.path) > 0 {
+			files = append(files, f)
+		}
+	}
+
+	var written []string
+	cleanup := func() {
+		for _, tmp := range written {
+			_ = os.Remove(tmp)
+		}
+	}
+	for _, f := range files {
+		tmp := f.path + ".tmp"
+		if err := writer.WriteJson(f.data, tmp, writer.PrettyPrint); err != nil {
+			cleanup()
+			return err
+		}
+		written = append(written, tmp)
+	}
+	for i, f := range files {
+		if err := os.Rename(written[i], f.path); err != nil {
+			cleanup()
+			return err
+		}
+	}
+	return nil
+}
+
+// Changed tells if the listing of the event has changed so that its details should be fetched again
+func Changed(prev models.Event, cur models.Event) bool {
+	return prev.Headline != cur.Headline ||
+		prev.EventLink != cur.EventLink ||
+		prev.SmallImageLink != cur.SmallImageLink ||
+		prev.Weekday != cur.Weekday ||
+		prev.Date != cur.Date ||
+		prev.StoreLink != cur.StoreLink ||
+		prev.InStock != cur.InStock ||
+		prev.Archived ||
+		!slices.Equal(prev.BulletPoints, cur.BulletPoints)
+}
+
+func byID[T models.HasID](elements []T) map[string]T {
+	m := make(map[string]T, len(elements))
+	for _, e := range elements {
+		m[e.ID()] = e
+	}
+	return m
+}
+
+// Stale returns the events of the listing which are new, changed or do not have details in the snapshot
+func (s Snapshot) Stale(listing []models.Event) []models.Event {
+	events := byID(s.Events)
+	details := byID(s.Details)
+
+	var stale []models.Event
+	for _, e := range listing {
+		prev, ok := events[e.ID()]
+		if _, hasDetails := details[e.ID()]; !ok || !hasDetails || Changed(prev, e) {
+			stale = append(stale, e)
+		}
+	}
+	return stale
+}
+
+// MissingAscii returns the details whose image is not in the snapshot or has changed since
+func (s Snapshot) MissingAscii(details []models.EventDetails) []models.EventDetails {
+	prevDetails := byID(s.Details)
+	ascii := byID(s.Ascii)
+
+	var missing []models.EventDetails
+	for _, ed := range details {
+		prev, hasPrev := prevDetails[ed.ID()]
+		_, hasAscii := ascii[ed.ID()]
+		if !hasAscii || !hasPrev || prev.ImageURL() != ed.ImageURL() {
+			missing = append(missing, ed)
+		}
+	}
+	return missing
+}
+
+// Summary contains the amounts of events in a merge, Kept are the events of the sources which could not be listed
+type Summary struct {
+	New       int
+	Changed   int
+	Unchanged int
+	Archived  int
+	Kept      int
+}
+
+// Merge merges the listing and the fetched details and ascii into the snapshot. The listing replaces the previous
+// one, the events missing from it are kept as archived after the listed ones. The events of the unlisted sources,
+// which failed to list their events, are kept as they were. Fetched details and ascii replace the previous ones, the
+// previous ones are kept for the events which were not fetched or failed.
+func (s Snapshot) Merge(listing []models.Event, details []models.EventDetails, ascii []models.EventAscii, unlisted ...string) (Snapshot, Summary) {
+	var summary Summary
+	prevEvents := byID(s.Events)
+	listed := map[string]bool{}
+
+	var merged Snapshot
+	for _, e := range listing {
+		listed[e.ID()] = true
+		prev, ok := prevEvents[e.ID()]
+		switch {
+		case !ok:
+			summary.New++
+		case Changed(prev, e):
+			summary.Changed++
+		default:
+			summary.Unchanged++
+			// the listing is timestamped when it is fetched, an unchanged event keeps the time it last changed
+			e.UpdatedAt = prev.UpdatedAt
+		}
+		e.Archived = false
+		merged.Events = append(merged.Events, e)
+	}
+	kept := func(e models.Event) bool {
+		return slices.Contains(unlisted, e.Source)
+	}
+	for _, e := range s.Events {
+		if listed[e.ID()] || !kept(e) {
+			continue
+		}
+		if !e.Archived {
+			summary.Kept++
+		}
+		e.Order = len(merged.Events)
+		merged.Events = append(merged.Events, e)
+	}
+	for _, e := range s.Events {
+		if listed[e.ID()] || kept(e) {
+			continue
+		}
+		if !e.Archived {
+			summary.Archived++
+		}
+		e.Archived = true
+		e.Order = len(merged.Events)
+		merged.Events = append(merged.Events, e)
+	}
+
+	merged.Details = mergeByEvents(merged.Events, s.Details, details)
+	merged.Ascii = mergeByEvents(merged.Events, s.Ascii, ascii)
+	return merged, summary
+}
+
+// mergeByEvents picks the fetched or else the previous element for each event in the order of the events
+func mergeByEvents[T models.HasID](events []models.Event, prev []T, fetched []T) []T {
+	prevByID := byID(prev)
+	fetchedByID := byID(fetched)
+
+	merged := make([]T, 0, len(events))
+	for _, e := range events {
+		if v, ok := fetchedByID[e.ID()]; ok {
+			merged = append(merged, v)
+		} else if v, ok := prevByID[e.ID()]; ok {
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
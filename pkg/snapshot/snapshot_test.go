@@ -0,0 +1,213 @@
+package snapshot
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func previous() Snapshot {
+	return Snapshot{
+		Events: []models.Event{
+			{Id: "same", Headline: "Same", InStock: true},
+			{Id: "sold-out", Headline: "Sold Out", InStock: true},
+			{Id: "gone", Headline: "Gone"},
+			{Id: "no-details", Headline: "No Details"},
+		},
+		Details: []models.EventDetails{
+			{EventID: "same", ImageLink: "same.png"},
+			{EventID: "sold-out", ImageLink: "sold-out.png"},
+			{EventID: "gone"},
+		},
+		Ascii: []models.EventAscii{
+			{EventID: "same", Ascii: "same"},
+			{EventID: "sold-out", Ascii: "sold-out"},
+		},
+	}
+}
+
+func listing() []models.Event {
+	return []models.Event{
+		{Id: "new", Headline: "New", Order: 0},
+		{Id: "same", Headline: "Same", InStock: true, Order: 1},
+		{Id: "sold-out", Headline: "Sold Out", InStock: false, Order: 2},
+		{Id: "no-details", Headline: "No Details", Order: 3},
+	}
+}
+
+func ids[T models.HasID](elements []T) []string {
+	var rs []string
+	for _, e := range elements {
+		rs = append(rs, e.ID())
+	}
+	return rs
+}
+
+func equal(a []string, b ...string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestStale(t *testing.T) {
+	stale := ids(previous().Stale(listing()))
+	if !equal(stale, "new", "sold-out", "no-details") {
+		t.Errorf("unexpected stale events %v", stale)
+	}
+}
+
+func TestMissingAscii(t *testing.T) {
+	fetched := []models.EventDetails{
+		{EventID: "new", ImageLink: "new.png"},
+		{EventID: "same", ImageLink: "same.png"},
+		{EventID: "sold-out", ImageLink: "changed.png"},
+	}
+	missing := ids(previous().MissingAscii(fetched))
+	if !equal(missing, "new", "sold-out") {
+		t.Errorf("unexpected missing ascii %v", missing)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	// details for sold-out failed so the previous ones should be kept
+	fetched := []models.EventDetails{{EventID: "new"}, {EventID: "no-details"}}
+	ascii := []models.EventAscii{{EventID: "new", Ascii: "new"}}
+
+	merged, summary := previous().Merge(listing(), fetched, ascii)
+
+	if !equal(ids(merged.Events), "new", "same", "sold-out", "no-details", "gone") {
+		t.Errorf("unexpected events %v", ids(merged.Events))
+	}
+	gone := merged.Events[4]
+	if !gone.Archived || gone.Order != 4 {
+		t.Errorf("missing event was not archived after the listed ones: %+v", gone)
+	}
+	if !equal(ids(merged.Details), "new", "same", "sold-out", "no-details", "gone") {
+		t.Errorf("unexpected details %v", ids(merged.Details))
+	}
+	if merged.Details[2].ImageLink != "sold-out.png" {
+		t.Errorf("previous details were not kept for a failed fetch")
+	}
+	if !equal(ids(merged.Ascii), "new", "same", "sold-out") {
+		t.Errorf("unexpected ascii %v", ids(merged.Ascii))
+	}
+	if summary != (Summary{New: 1, Changed: 1, Unchanged: 2, Archived: 1}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	// archived events are counted only once and come back when listed again
+	_, summary = merged.Merge(listing(), nil, nil)
+	if summary.Archived != 0 {
+		t.Errorf("already archived event was counted again")
+	}
+	relisted, _ := merged.Merge(append(listing(), models.Event{Id: "gone"}), nil, nil)
+	if relisted.Events[4].Archived {
+		t.Errorf("listed event should not be archived")
+	}
+}
+
+func TestMergeFailedSource(t *testing.T) {
+	prev := Snapshot{
+		Events: []models.Event{
+			{Id: "a-1", Source: "a"},
+			{Id: "b-1", Source: "b"},
+			{Id: "b-2", Source: "b", Archived: true},
+		},
+		Details: []models.EventDetails{{EventID: "b-1", ImageLink: "b-1.png"}},
+	}
+
+	// b could not be listed so its events are kept as they were
+	merged, summary := prev.Merge([]models.Event{{Id: "a-2", Source: "a"}}, nil, nil, "b")
+
+	if !equal(ids(merged.Events), "a-2", "b-1", "b-2", "a-1") {
+		t.Errorf("unexpected events %v", ids(merged.Events))
+	}
+	if merged.Events[1].Archived || !merged.Events[2].Archived || !merged.Events[3].Archived {
+		t.Errorf("only the missing event of the listed source should be archived: %+v", merged.Events)
+	}
+	if len(merged.Details) != 1 || merged.Details[0].ImageLink != "b-1.png" {
+		t.Errorf("details of the failed source were not kept: %+v", merged.Details)
+	}
+	if summary != (Summary{New: 1, Archived: 1, Kept: 1}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestMergeKeepsUpdatedAt(t *testing.T) {
+	synced := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	prev := previous()
+	for i := range prev.Events {
+		prev.Events[i].UpdatedAt = synced
+	}
+	now := synced.Add(24 * time.Hour)
+	cur := listing()
+	for i := range cur {
+		cur[i].UpdatedAt = now
+	}
+
+	merged, _ := prev.Merge(cur, nil, nil)
+	for _, e := range merged.Events {
+		want := now
+		if e.Id == "same" || e.Id == "no-details" || e.Id == "gone" {
+			want = synced
+		}
+		if !e.UpdatedAt.Equal(want) {
+			t.Errorf("event %s updated at %s, want %s", e.Id, e.UpdatedAt, want)
+		}
+	}
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	p := Paths{
+		Events:  filepath.Join(dir, "events.json"),
+		Details: filepath.Join(dir, "event_details.json"),
+		Ascii:   filepath.Join(dir, "event_ascii.json"),
+	}
+
+	empty, err := Load(p)
+	if err != nil || len(empty.Events) != 0 {
+		t.Fatalf("missing files should load as empty, got err %v", err)
+	}
+
+	if err = previous().Write(p); err != nil {
+		t.Fatalf("err writing snapshot: %s", err)
+	}
+	loaded, err := Load(p)
+	if err != nil {
+		t.Fatalf("err loading snapshot: %s", err)
+	}
+	if len(loaded.Events) != 4 || len(loaded.Details) != 3 || len(loaded.Ascii) != 2 {
+		t.Errorf("snapshot was not written fully: %d %d %d", len(loaded.Events), len(loaded.Details), len(loaded.Ascii))
+	}
+
+	// only the files with a path are written
+	if err = (Snapshot{Events: previous().Events[:1]}).Write(Paths{Events: p.Events}); err != nil {
+		t.Fatalf("err writing events: %s", err)
+	}
+	if loaded, _ = Load(p); len(loaded.Events) != 1 || len(loaded.Details) != 3 {
+		t.Errorf("writing the events changed the other files: %d %d", len(loaded.Events), len(loaded.Details))
+	}
+
+	tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(tmps) != 0 {
+		t.Errorf("temporary files were left behind: %v", tmps)
+	}
+
+	// a failing write keeps the previous snapshot
+	p.Ascii = filepath.Join(dir, "missing", "dir", "\x00")
+	if err = (Snapshot{}).Write(p); err == nil {
+		t.Errorf("expected err writing into an invalid path")
+	}
+	if data, _ := os.ReadFile(p.Events); len(data) < 10 {
+		t.Errorf("previous events were overwritten by a failed write")
+	}
+}
@@ -0,0 +1,51 @@
+package sources
+
+import (
+	"context"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/fetch"
+)
+
+const (
+	// Jelmu is the name of the source for Lutakko, scraped from the WooCommerce markup of jelmu.net
+	Jelmu = "jelmu"
+	// JelmuURL is the default address of the jelmu source
+	JelmuURL = "https://www.jelmu.net"
+	// JelmuVenue is the venue of the events listed on jelmu.net
+	JelmuVenue = "Lutakko"
+)
+
+type jelmu struct {
+	baseURL string
+}
+
+func init() {
+	Register(Jelmu, JelmuURL, func(baseURL string) Source {
+		return jelmu{baseURL: baseURL}
+	})
+}
+
+func (s jelmu) Name() string {
+	return Jelmu
+}
+
+func (s jelmu) Events(ctx context.Context) ([]models.Event, error) {
+	events, err := fetch.Sync.Events(ctx, s.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	tag(events, Jelmu, JelmuVenue)
+	return events, nil
+}
+
+func (s jelmu) EventDetails(ctx context.Context, eventURL string, eventID string) (models.EventDetails, error) {
+	return fetch.Sync.EventDetails(ctx, eventURL, eventID)
+}
+
+func (s jelmu) EventImage(ctx context.Context, imageURL string, eventID string) (models.EventAscii, error) {
+	return fetch.Sync.EventImage(ctx, imageURL, eventID)
+}
+
+func (s jelmu) Handles(u string) bool {
+	return sameHost(s.baseURL, u)
+}
@@ -0,0 +1,186 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Multi aggregates several sources into a single merged listing, details and images are fetched from the source
+// which listed the event
+type Multi struct {
+	sources []Source
+	mtx     sync.Mutex
+	owners  map[string]Source
+}
+
+// NewMulti aggregates the sources, the order of the sources is the priority when the same event is listed twice
+func NewMulti(srcs ...Source) *Multi {
+	return &Multi{
+		sources: srcs,
+		owners:  map[string]Source{},
+	}
+}
+
+func (m *Multi) Name() string {
+	var names []string
+	for _, s := range m.sources {
+		names = append(names, s.Name())
+	}
+	return strings.Join(names, "+")
+}
+
+// Events fetches the listings of all the sources concurrently and merges them, a failing source is skipped as long
+// as one of them succeeds
+func (m *Multi) Events(ctx context.Context) ([]models.Event, error) {
+	events, _, err := m.Listings(ctx)
+	return events, err
+}
+
+// Listings is Events which returns the names of the skipped sources as well, their events are missing from the
+// listing only because they could not be listed
+func (m *Multi) Listings(ctx context.Context) ([]models.Event, []string, error) {
+	listings := make([][]models.Event, len(m.sources))
+	errs := make([]error, len(m.sources))
+
+	var wg sync.WaitGroup
+	for i, s := range m.sources {
+		wg.Add(1)
+		go func(i int, s Source) {
+			defer wg.Done()
+			listings[i], errs[i] = s.Events(ctx)
+		}(i, s)
+	}
+	wg.Wait()
+
+	var (
+		failed      []error
+		failedNames []string
+	)
+	for i, err := range errs {
+		if err != nil {
+			logger.Log.Warnf("could not list events from source %s: %v", m.sources[i].Name(), err)
+			failed = append(failed, fmt.Errorf("%s: %w", m.sources[i].Name(), err))
+			failedNames = append(failedNames, m.sources[i].Name())
+		}
+	}
+	if len(failed) == len(m.sources) {
+		return nil, failedNames, errors.Join(failed...)
+	}
+
+	m.mtx.Lock()
+	for i, l := range listings {
+		for _, e := range l {
+			m.owners[e.Id] = m.sources[i]
+		}
+	}
+	m.mtx.Unlock()
+
+	return Merge(listings...), failedNames, nil
+}
+
+func (m *Multi) EventDetails(ctx context.Context, eventURL string, eventID string) (models.EventDetails, error) {
+	s, err := m.owner(eventID, eventURL)
+	if err != nil {
+		return models.EventDetails{}, err
+	}
+	return s.EventDetails(ctx, eventURL, eventID)
+}
+
+func (m *Multi) EventImage(ctx context.Context, imageURL string, eventID string) (models.EventAscii, error) {
+	s, err := m.owner(eventID, imageURL)
+	if err != nil {
+		return models.EventAscii{}, err
+	}
+	return s.EventImage(ctx, imageURL, eventID)
+}
+
+func (m *Multi) Handles(u string) bool {
+	for _, s := range m.sources {
+		if s.Handles(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// owner resolves the source of the event, first from the listings and then from the url
+func (m *Multi) owner(eventID string, u string) (Source, error) {
+	m.mtx.Lock()
+	s, ok := m.owners[eventID]
+	m.mtx.Unlock()
+	if ok {
+		return s, nil
+	}
+	for _, s := range m.sources {
+		if s.Handles(u) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no source for event %s", eventID)
+}
+
+// Merge merges the listings into one ordered by the start time, events without a start time are kept after the
+// others in their original order. The same event listed twice, by ID or by the day and headline, is kept only from
+// the first listing. A single listing is returned as it is.
+func Merge(listings ...[]models.Event) []models.Event {
+	var nonEmpty [][]models.Event
+	for _, l := range listings {
+		if len(l) > 0 {
+			nonEmpty = append(nonEmpty, l)
+		}
+	}
+	if len(nonEmpty) == 1 {
+		return nonEmpty[0]
+	}
+
+	var merged []models.Event
+	seen := map[string]bool{}
+	for _, l := range nonEmpty {
+		for _, e := range l {
+			key := duplicateKey(e)
+			if seen[e.Id] || (len(key) > 0 && seen[key]) {
+				continue
+			}
+			seen[e.Id] = true
+			if len(key) > 0 {
+				seen[key] = true
+			}
+			merged = append(merged, e)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i].StartsAt, merged[j].StartsAt
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+	for i := range merged {
+		merged[i].Order = i
+	}
+	return merged
+}
+
+// duplicateKey identifies the same gig listed by different sources, empty if the start is unknown
+func duplicateKey(e models.Event) string {
+	if e.StartsAt == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(e.StartsAt.Format("2006-01-02"))
+	sb.WriteRune('|')
+	for _, r := range strings.ToLower(e.Headline) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/fetch"
+	"github.com/johannessarpola/lutakkols/pkg/ratelimit"
+	"github.com/johannessarpola/lutakkols/pkg/workset"
+	"sort"
+	"time"
+)
+
+// DefaultTaskTimeout is used when the pool is not given a timeout for the tasks
+const DefaultTaskTimeout = 20 * time.Second
+
+// timeoutGrace is given to the tasks over the per task timeout so that they can report the timeout themselves
+const timeoutGrace = time.Second
+
+// PoolConfig controls how the details are fetched concurrently, Limiter is shared between the workers
+type PoolConfig struct {
+	Workers     int
+	TaskTimeout time.Duration
+	Limiter     *ratelimit.Bucket
+}
+
+// EventError is a failed fetch for a single event
+type EventError struct {
+	EventID string
+	Err     error
+}
+
+func (e EventError) Error() string {
+	return fmt.Sprintf("event %s: %v", e.EventID, e.Err)
+}
+
+func (e EventError) Unwrap() error {
+	return e.Err
+}
+
+// run runs the fetch for each item in the pool and returns the successful results in the order of the items
+func run[I models.HasID, T any](ctx context.Context, items []I, conf PoolConfig, fetchFn func(context.Context, I) (T, error)) ([]T, []EventError) {
+	if conf.TaskTimeout <= 0 {
+		conf.TaskTimeout = DefaultTaskTimeout
+	}
+
+	var tasks []workset.Task[T]
+	for _, item := range items {
+		it := item
+		tasks = append(tasks, func() (T, error) {
+			tctx, cancel := context.WithTimeout(ctx, conf.TaskTimeout)
+			defer cancel()
+			if err := conf.Limiter.Wait(tctx); err != nil {
+				var empty T
+				return empty, err
+			}
+			return fetchFn(tctx, it)
+		})
+	}
+
+	results := workset.NewWorkSet(tasks, conf.Workers, conf.TaskTimeout+timeoutGrace).Collect()
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Index < results[j].Index
+	})
+
+	values := make([]T, 0, len(results))
+	var errs []EventError
+	for _, r := range results {
+		if r.Error != nil {
+			errs = append(errs, EventError{EventID: items[r.Index].ID(), Err: r.Error})
+			continue
+		}
+		values = append(values, r.Value)
+	}
+	return values, errs
+}
+
+// FetchDetails fetches the details of the events concurrently from the source, the schedule is parsed on the day of
+// the event if the source could not resolve the day from the details
+func FetchDetails(ctx context.Context, src Source, events []models.Event, conf PoolConfig) ([]models.EventDetails, []EventError) {
+	return run(ctx, events, conf, func(ctx context.Context, e models.Event) (models.EventDetails, error) {
+		v, err := src.EventDetails(ctx, e.EventURL(), e.ID())
+		if err == nil && v.DoorsOpen == nil && v.SetTimes == nil && e.StartsAt != nil {
+			fetch.ApplySchedule(&v, *e.StartsAt)
+		}
+		return v, err
+	})
+}
+
+// FetchImages fetches the images of the events concurrently from the source and converts them into ascii art,
+// details without an image are skipped
+func FetchImages(ctx context.Context, src Source, details []models.EventDetails, conf PoolConfig) ([]models.EventAscii, []EventError) {
+	var withImage []models.EventDetails
+	for _, ed := range details {
+		if len(ed.ImageURL()) > 0 {
+			withImage = append(withImage, ed)
+		}
+	}
+	return run(ctx, withImage, conf, func(ctx context.Context, ed models.EventDetails) (models.EventAscii, error) {
+		return src.EventImage(ctx, ed.ImageURL(), ed.ID())
+	})
+}
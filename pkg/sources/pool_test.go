@@ -0,0 +1,83 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/ratelimit"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type slowSource struct {
+	fakeSource
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (s *slowSource) EventDetails(ctx context.Context, _ string, eventID string) (models.EventDetails, error) {
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	if n > s.peak.Load() {
+		s.peak.Store(n)
+	}
+
+	switch eventID {
+	case "broken":
+		return models.EventDetails{}, errors.New("broken page")
+	case "hanging":
+		<-ctx.Done()
+		return models.EventDetails{}, ctx.Err()
+	}
+	time.Sleep(20 * time.Millisecond)
+	return models.EventDetails{EventID: eventID}, nil
+}
+
+func TestFetchDetails(t *testing.T) {
+	src := &slowSource{}
+	var events []models.Event
+	for i := 0; i < 12; i++ {
+		events = append(events, models.Event{Id: fmt.Sprintf("event-%02d", i)})
+	}
+	events = append(events, models.Event{Id: "broken"}, models.Event{Id: "hanging"})
+
+	conf := PoolConfig{
+		Workers:     4,
+		TaskTimeout: 200 * time.Millisecond,
+		Limiter:     ratelimit.New(time.Millisecond, 4),
+	}
+	details, failures := FetchDetails(context.Background(), src, events, conf)
+
+	if len(details) != 12 {
+		t.Fatalf("expected 12 details, got %d", len(details))
+	}
+	for i, d := range details {
+		if d.EventID != events[i].Id {
+			t.Errorf("details are not in the order of the events, %s at %d", d.EventID, i)
+		}
+	}
+	if len(failures) != 2 || failures[0].EventID != "broken" || failures[1].EventID != "hanging" {
+		t.Errorf("unexpected failures %v", failures)
+	}
+	if !errors.Is(failures[1], context.DeadlineExceeded) {
+		t.Errorf("expected the task to time out, got %v", failures[1].Err)
+	}
+	if peak := src.peak.Load(); peak < 2 || peak > 4 {
+		t.Errorf("expected concurrent fetches limited by the workers, peak was %d", peak)
+	}
+}
+
+func TestFetchImages(t *testing.T) {
+	src := &fakeSource{name: "a"}
+	details := []models.EventDetails{
+		{EventID: "with-image", ImageLink: "https://a.fi/image.png"},
+		{EventID: "without-image"},
+	}
+
+	ascii, failures := FetchImages(context.Background(), src, details, PoolConfig{Workers: 2})
+	if len(failures) != 0 || len(ascii) != 1 || ascii[0].EventID != "with-image" {
+		t.Errorf("expected ascii only for the event with image, got %+v %v", ascii, failures)
+	}
+}
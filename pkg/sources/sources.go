@@ -0,0 +1,129 @@
+// Package sources contains the event sources and a registry for them, each venue or ticket site is a source and
+// several of them can be aggregated into a single listing with Multi
+package sources
+
+import (
+	"context"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Source lists events and fetches their details and images from a single site. Event IDs should be unique between
+// sources, a source should prefix them with its name if they could collide with the ones of another source
+type Source interface {
+	// Name is the registered name of the source, it is stored into the events it lists
+	Name() string
+	// Events lists the upcoming events
+	Events(ctx context.Context) ([]models.Event, error)
+	// EventDetails fetches the details from the event page
+	EventDetails(ctx context.Context, eventURL string, eventID string) (models.EventDetails, error)
+	// EventImage fetches the image and converts it into ascii art
+	EventImage(ctx context.Context, imageURL string, eventID string) (models.EventAscii, error)
+	// Handles tells if the url belongs to the site of the source
+	Handles(u string) bool
+}
+
+// List lists the events of the source, for aggregated sources the names of the sources which could not be listed
+// are returned as well
+func List(ctx context.Context, src Source) ([]models.Event, []string, error) {
+	if m, ok := src.(*Multi); ok {
+		return m.Listings(ctx)
+	}
+	events, err := src.Events(ctx)
+	return events, nil, err
+}
+
+// Factory creates the source for the base URL
+type Factory func(baseURL string) Source
+
+type registration struct {
+	defaultURL string
+	factory    Factory
+}
+
+var (
+	registryMtx sync.RWMutex
+	registry    = map[string]registration{}
+)
+
+// Register adds a source into the registry with the URL used when none is given
+func Register(name string, defaultURL string, factory Factory) {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+	registry[name] = registration{defaultURL: defaultURL, factory: factory}
+}
+
+// Names returns the names of the registered sources in alphabetical order
+func Names() []string {
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates a registered source, with empty baseURL the default of the source is used
+func New(name string, baseURL string) (Source, error) {
+	registryMtx.RLock()
+	r, ok := registry[name]
+	registryMtx.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown source %s, available sources are %s", name, strings.Join(Names(), ", "))
+	}
+	if len(baseURL) == 0 {
+		baseURL = r.defaultURL
+	}
+	return r.factory(baseURL), nil
+}
+
+// FromSpecs creates the sources from specs in form of name or name=url, several sources are aggregated with Multi
+func FromSpecs(specs ...string) (Source, error) {
+	var srcs []Source
+	for _, spec := range specs {
+		name, baseURL, _ := strings.Cut(strings.TrimSpace(spec), "=")
+		src, err := New(name, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, src)
+	}
+
+	switch len(srcs) {
+	case 0:
+		return nil, fmt.Errorf("no sources given")
+	case 1:
+		return srcs[0], nil
+	default:
+		return NewMulti(srcs...), nil
+	}
+}
+
+// tag marks the events listed by the source, venue is used only if the event does not have one
+func tag(events []models.Event, source string, venue string) {
+	for i := range events {
+		events[i].Source = source
+		if len(events[i].Venue) == 0 {
+			events[i].Venue = venue
+		}
+	}
+}
+
+// sameHost tells if the url is on the host of the base url, www prefix is ignored
+func sameHost(baseURL string, u string) bool {
+	b, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	o, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	return len(b.Host) > 0 && strings.TrimPrefix(b.Host, "www.") == strings.TrimPrefix(o.Host, "www.")
+}
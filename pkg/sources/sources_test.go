@@ -0,0 +1,158 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/fetch/replay"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	name    string
+	host    string
+	events  []models.Event
+	err     error
+	fetched []string
+}
+
+func (f *fakeSource) Name() string { return f.name }
+
+func (f *fakeSource) Events(_ context.Context) ([]models.Event, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	events := append([]models.Event(nil), f.events...)
+	tag(events, f.name, f.name+" venue")
+	return events, nil
+}
+
+func (f *fakeSource) EventDetails(_ context.Context, _ string, eventID string) (models.EventDetails, error) {
+	f.fetched = append(f.fetched, eventID)
+	return models.EventDetails{EventID: eventID}, nil
+}
+
+func (f *fakeSource) EventImage(_ context.Context, _ string, eventID string) (models.EventAscii, error) {
+	f.fetched = append(f.fetched, eventID)
+	return models.EventAscii{EventID: eventID}, nil
+}
+
+func (f *fakeSource) Handles(u string) bool { return sameHost(f.host, u) }
+
+func day(d int) *time.Time {
+	t := time.Date(2024, 10, d, 20, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestFromSpecs(t *testing.T) {
+	src, err := FromSpecs("jelmu=http://localhost:1234")
+	if err != nil {
+		t.Fatalf("err creating source: %s", err)
+	}
+	if src.Name() != Jelmu || !src.Handles("http://localhost:1234/tapahtuma/x/") {
+		t.Errorf("single spec should create the source itself, got %s", src.Name())
+	}
+
+	src, err = FromSpecs("jelmu", "jelmu=http://localhost:1234")
+	if err != nil {
+		t.Fatalf("err creating sources: %s", err)
+	}
+	if _, ok := src.(*Multi); !ok || src.Name() != "jelmu+jelmu" {
+		t.Errorf("expected multi source, got %s", src.Name())
+	}
+
+	if _, err = FromSpecs("nonexistent"); err == nil {
+		t.Errorf("expected err for unknown source")
+	}
+	if _, err = FromSpecs(); err == nil {
+		t.Errorf("expected err for no sources")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := []models.Event{
+		{Id: "a-late", Headline: "Late Band", StartsAt: day(20)},
+		{Id: "shared", Headline: "Shared Band", StartsAt: day(10)},
+		{Id: "a-unknown", Headline: "No Date"},
+	}
+	b := []models.Event{
+		{Id: "b-early", Headline: "Early Band", StartsAt: day(1)},
+		{Id: "b-shared", Headline: "SHARED band!", StartsAt: day(10)},
+		{Id: "shared", Headline: "Same id", StartsAt: day(15)},
+	}
+
+	merged := Merge(a, b)
+	want := []string{"b-early", "shared", "a-late", "a-unknown"}
+	if len(merged) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(merged))
+	}
+	for i, id := range want {
+		if merged[i].Id != id || merged[i].Order != i {
+			t.Errorf("expected %s at %d, got %s with order %d", id, i, merged[i].Id, merged[i].Order)
+		}
+	}
+
+	single := Merge(nil, a)
+	if len(single) != len(a) || single[0].Id != "a-late" {
+		t.Errorf("single listing should be kept as it is")
+	}
+}
+
+func TestMulti(t *testing.T) {
+	a := &fakeSource{name: "a", host: "https://a.fi", events: []models.Event{{Id: "a-1", StartsAt: day(2)}}}
+	b := &fakeSource{name: "b", host: "https://b.fi", events: []models.Event{{Id: "b-1", StartsAt: day(1)}}}
+	m := NewMulti(a, b)
+
+	events, err := m.Events(context.Background())
+	if err != nil {
+		t.Fatalf("err listing events: %s", err)
+	}
+	if len(events) != 2 || events[0].Source != "b" || events[0].Venue != "b venue" {
+		t.Errorf("unexpected merged listing %+v", events)
+	}
+
+	_, _ = m.EventDetails(context.Background(), "https://elsewhere.fi/1", "a-1")
+	_, _ = m.EventImage(context.Background(), "https://b.fi/image.png", "unknown")
+	if len(a.fetched) != 1 || len(b.fetched) != 1 {
+		t.Errorf("fetches were not routed to the owning sources: a=%v b=%v", a.fetched, b.fetched)
+	}
+	if _, err = m.EventDetails(context.Background(), "https://elsewhere.fi/2", "unknown"); err == nil {
+		t.Errorf("expected err for an event without a source")
+	}
+
+	b.err = errors.New("down")
+	events, unlisted, err := List(context.Background(), m)
+	if err != nil || len(events) != 1 {
+		t.Errorf("expected the listing of the working source, got %d events and err %v", len(events), err)
+	}
+	if len(unlisted) != 1 || unlisted[0] != "b" {
+		t.Errorf("expected the failed source to be reported, got %v", unlisted)
+	}
+	a.err = errors.New("down")
+	if _, err = m.Events(context.Background()); err == nil {
+		t.Errorf("expected err when all sources fail")
+	}
+}
+
+func TestJelmu(t *testing.T) {
+	srv := replay.NewServer("../fetch/test_data/fixtures")
+	defer srv.Close()
+
+	src, err := New(Jelmu, srv.URL)
+	if err != nil {
+		t.Fatalf("err creating source: %s", err)
+	}
+	events, err := src.Events(context.Background())
+	if err != nil {
+		t.Fatalf("err listing events: %s", err)
+	}
+	if len(events) == 0 {
+		t.Fatalf("no events listed")
+	}
+	for _, e := range events {
+		if e.Source != Jelmu || e.Venue != JelmuVenue {
+			t.Errorf("event %s was not tagged with the source: %s %s", e.Id, e.Source, e.Venue)
+		}
+	}
+}
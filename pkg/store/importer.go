@@ -0,0 +1,63 @@
+package store
+
+import (
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/writer"
+	"os"
+)
+
+// ImportResult contains the amounts of imported elements
+type ImportResult struct {
+	Events  int
+	Details int
+}
+
+// ImportJson imports the events and event details json files written by sync into the store, the details file
+// is optional
+func (s *Store) ImportJson(eventsPath string, detailsPath string) (ImportResult, error) {
+	var (
+		rs      ImportResult
+		events  []models.Event
+		details []models.EventDetails
+	)
+
+	stat, err := os.Stat(eventsPath)
+	if err != nil {
+		return rs, err
+	}
+	if err = writer.ReadJson(eventsPath, &events); err != nil {
+		return rs, err
+	}
+	if err = s.SaveEvents(models.Events{Events: events, UpdatedAt: stat.ModTime()}); err != nil {
+		return rs, err
+	}
+	rs.Events = len(events)
+
+	if _, err = os.Stat(detailsPath); os.IsNotExist(err) {
+		return rs, nil
+	}
+	if err = writer.ReadJson(detailsPath, &details); err != nil {
+		return rs, err
+	}
+	if err = s.SaveDetails(details...); err != nil {
+		return rs, err
+	}
+	rs.Details = len(details)
+	return rs, nil
+}
+
+// ImportAscii imports the ascii art json file written by sync into the store, a missing file is not an error
+func (s *Store) ImportAscii(asciiPath string) (int, error) {
+	var ascii []models.EventAscii
+
+	if _, err := os.Stat(asciiPath); os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err := writer.ReadJson(asciiPath, &ascii); err != nil {
+		return 0, err
+	}
+	if err := s.SaveAscii(ascii...); err != nil {
+		return 0, err
+	}
+	return len(ascii), nil
+}
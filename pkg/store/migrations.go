@@ -0,0 +1,71 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/logger"
+)
+
+// migrations are applied in order and the index+1 is stored as the schema version, never edit an existing migration
+var migrations = []string{
+	// 1: initial schema, models are stored as json with indexed ids
+	`CREATE TABLE events (
+		id         TEXT PRIMARY KEY,
+		ord        INTEGER NOT NULL,
+		data       TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE INDEX events_ord ON events (ord);
+	CREATE TABLE event_details (
+		event_id   TEXT PRIMARY KEY,
+		data       TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE event_ascii (
+		event_id   TEXT PRIMARY KEY,
+		ascii      TEXT NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE meta (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`,
+}
+
+func schemaVersion(db *sql.DB) (int, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL)`)
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	err = db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// migrate applies the migrations which have not been applied yet, each in its own transaction
+func migrate(db *sql.DB) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		logger.Log.Debugf("applying store migration %d", i+1)
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(migrations[i]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", i+1, err)
+		}
+		if _, err = tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package store contains the SQLite backed persistent storage for the synchronized events
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	_ "modernc.org/sqlite" // pure go driver so that no cgo is needed
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const eventsUpdatedKey = "events_updated_at"
+
+// NotFoundError is returned when there is no element with the id in the store
+type NotFoundError struct {
+	ID   string
+	Kind string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("could not find %s with id %s in store", e.Kind, e.ID)
+}
+
+// Store is the handle to the database, it is safe to use concurrently
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the database in path and applies the missing migrations
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	dsn := url.URL{
+		Scheme:   "file",
+		Path:     path,
+		OmitHost: true,
+		RawQuery: "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+	}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	// sqlite allows a single writer
+	db.SetMaxOpenConns(1)
+
+	if err = migrate(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SaveEvents replaces the stored event listing with the events, of the events with the same id the last one is kept
+func (s *Store) SaveEvents(events models.Events) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM events`); err != nil {
+		return err
+	}
+	for _, e := range events.Events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO events (id, ord, data, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET ord = excluded.ord, data = excluded.data, updated_at = excluded.updated_at`,
+			e.ID(), e.Order, string(data), e.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	updatedAt := events.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+	_, err = tx.Exec(`INSERT INTO meta (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value`,
+		eventsUpdatedKey, updatedAt.Format(time.RFC3339Nano))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveDetails inserts or updates the details
+func (s *Store) SaveDetails(details ...models.EventDetails) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, ed := range details {
+		data, err := json.Marshal(ed)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO event_details (event_id, data, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT (event_id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+			ed.ID(), string(data), ed.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveAscii inserts or updates the ascii art
+func (s *Store) SaveAscii(ascii ...models.EventAscii) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, ea := range ascii {
+		_, err = tx.Exec(`INSERT INTO event_ascii (event_id, ascii, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT (event_id) DO UPDATE SET ascii = excluded.ascii, updated_at = excluded.updated_at`,
+			ea.ID(), ea.Ascii, ea.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Events loads the event listing in order
+func (s *Store) Events(ctx context.Context) (*models.Events, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT data FROM events ORDER BY ord`)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	events := models.Events{}
+	for rows.Next() {
+		var (
+			data string
+			e    models.Event
+		)
+		if err = rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, err
+		}
+		events.Events = append(events.Events, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var updatedAt string
+	err = s.db.QueryRowContext(ctx, `SELECT value FROM meta WHERE key = ?`, eventsUpdatedKey).Scan(&updatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	events.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+
+	return &events, nil
+}
+
+// Details loads the details of a single event
+func (s *Store) Details(ctx context.Context, eventID string) (models.EventDetails, error) {
+	var (
+		data string
+		ed   models.EventDetails
+	)
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM event_details WHERE event_id = ?`, eventID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ed, NotFoundError{ID: eventID, Kind: "details"}
+	}
+	if err != nil {
+		return ed, err
+	}
+	err = json.Unmarshal([]byte(data), &ed)
+	return ed, err
+}
+
+// Ascii loads the ascii art of a single event
+func (s *Store) Ascii(ctx context.Context, eventID string) (models.EventAscii, error) {
+	ea := models.EventAscii{EventID: eventID}
+	err := s.db.QueryRowContext(ctx, `SELECT ascii, updated_at FROM event_ascii WHERE event_id = ?`, eventID).
+		Scan(&ea.Ascii, &ea.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ea, NotFoundError{ID: eventID, Kind: "ascii"}
+	}
+	return ea, err
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/johannessarpola/lutakkols/pkg/api/models"
+	"github.com/johannessarpola/lutakkols/pkg/writer"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	s, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("could not open store: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+	return s
+}
+
+func TestStore(t *testing.T) {
+	s := openTestStore(t)
+
+	events := models.Events{
+		Events: []models.Event{
+			{Id: "b", Order: 1, Headline: "Second"},
+			{Id: "a", Order: 0, Headline: "First"},
+		},
+		UpdatedAt: time.Now(),
+	}
+	if err := s.SaveEvents(events); err != nil {
+		t.Fatalf("could not save events: %s", err)
+	}
+
+	loaded, err := s.Events(context.Background())
+	if err != nil {
+		t.Fatalf("could not load events: %s", err)
+	}
+	if len(loaded.Events) != 2 || loaded.Events[0].Id != "a" {
+		t.Errorf("events were not loaded in order: %+v", loaded.Events)
+	}
+	if !loaded.UpdatedAt.Equal(events.UpdatedAt) {
+		t.Errorf("updated at was not stored")
+	}
+
+	err = s.SaveDetails(models.EventDetails{EventID: "a", PlayTimes: []string{"20:00"}})
+	if err != nil {
+		t.Fatalf("could not save details: %s", err)
+	}
+	ed, err := s.Details(context.Background(), "a")
+	if err != nil || len(ed.PlayTimes) != 1 {
+		t.Errorf("details were not loaded: %v", err)
+	}
+
+	_, err = s.Details(context.Background(), "missing")
+	var nf NotFoundError
+	if !errors.As(err, &nf) {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	if err = s.SaveAscii(models.EventAscii{EventID: "a", Ascii: "##"}); err != nil {
+		t.Fatalf("could not save ascii: %s", err)
+	}
+	ea, err := s.Ascii(context.Background(), "a")
+	if err != nil || ea.Ascii != "##" {
+		t.Errorf("ascii was not loaded: %v", err)
+	}
+}
+
+func TestStoreDuplicateIDs(t *testing.T) {
+	s := openTestStore(t)
+
+	err := s.SaveEvents(models.Events{Events: []models.Event{
+		{Id: "a", Order: 0, Headline: "First"},
+		{Id: "a", Order: 1, Headline: "Again"},
+	}})
+	if err != nil {
+		t.Fatalf("could not save events with duplicate ids: %s", err)
+	}
+	loaded, err := s.Events(context.Background())
+	if err != nil {
+		t.Fatalf("could not load events: %s", err)
+	}
+	if len(loaded.Events) != 1 || loaded.Events[0].Headline != "Again" {
+		t.Errorf("expected the last duplicate to be kept: %+v", loaded.Events)
+	}
+}
+
+func TestOpenEscapesPath(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "odd?name#1.db")
+	s, err := Open(p)
+	if err != nil {
+		t.Fatalf("could not open store: %s", err)
+	}
+	defer func() {
+		_ = s.Close()
+	}()
+	if _, err = os.Stat(p); err != nil {
+		t.Errorf("database was not created in %s: %s", p, err)
+	}
+}
+
+func TestImportJson(t *testing.T) {
+	dir := t.TempDir()
+	ep := filepath.Join(dir, "events.json")
+	edp := filepath.Join(dir, "event_details.json")
+	_ = writer.WriteJson([]models.Event{{Id: "event1"}, {Id: "event2", Order: 1}}, ep)
+	_ = writer.WriteJson([]models.EventDetails{{EventID: "event1"}}, edp)
+
+	s := openTestStore(t)
+	rs, err := s.ImportJson(ep, edp)
+	if err != nil {
+		t.Fatalf("import failed: %s", err)
+	}
+	if rs.Events != 2 || rs.Details != 1 {
+		t.Errorf("invalid import result %+v", rs)
+	}
+
+	eap := filepath.Join(dir, "event_ascii.json")
+	if n, err := s.ImportAscii(eap); err != nil || n != 0 {
+		t.Errorf("missing ascii file should be skipped, got %d with err %v", n, err)
+	}
+	_ = writer.WriteJson([]models.EventAscii{{EventID: "event1", Ascii: "###"}}, eap)
+	if n, err := s.ImportAscii(eap); err != nil || n != 1 {
+		t.Errorf("invalid ascii import %d with err %v", n, err)
+	}
+	if ea, err := s.Ascii(context.Background(), "event1"); err != nil || ea.Ascii != "###" {
+		t.Errorf("imported ascii not found: %v", err)
+	}
+
+	// reopening should not re-apply migrations
+	p := filepath.Join(dir, "reopen.db")
+	for i := 0; i < 2; i++ {
+		r, err := Open(p)
+		if err != nil {
+			t.Fatalf("could not reopen store: %s", err)
+		}
+		_ = r.Close()
+	}
+}
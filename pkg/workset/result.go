@@ -2,7 +2,9 @@ package workset
 
 import "time"
 
+// Result of a task, Index is the position of the task in the queued jobs
 type Result[T any] struct {
+	Index    int
 	Duration time.Duration
 	Value    T
 	Error    error
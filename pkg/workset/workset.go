@@ -10,7 +10,7 @@ import (
 
 // WorkSet struct is used to queue work concurrently
 type WorkSet[T any] struct {
-	taskQueue   chan Task[T]
+	taskQueue   chan indexedTask[T]
 	resultQueue chan Result[T]
 	taskTimeout time.Duration
 	workers     int
@@ -18,12 +18,20 @@ type WorkSet[T any] struct {
 	stopChan    chan struct{}
 }
 
+type indexedTask[T any] struct {
+	index int
+	task  Task[T]
+}
+
 // NewWorkSet workset which starts a bunch of jobs on n number of workers use Collect() to get the results
 func NewWorkSet[T any](jobs []Task[T], workers int, timeout time.Duration) *WorkSet[T] {
 	workAmount := len(jobs)
+	if workers < 1 {
+		workers = 1
+	}
 	pool := WorkSet[T]{
 		// tasks & results should be 1:1 as even a error is a result
-		taskQueue:   make(chan Task[T], workAmount),
+		taskQueue:   make(chan indexedTask[T], workAmount),
 		resultQueue: make(chan Result[T], workAmount),
 		taskTimeout: timeout,
 		workers:     workers,
@@ -37,8 +45,8 @@ func NewWorkSet[T any](jobs []Task[T], workers int, timeout time.Duration) *Work
 
 // queue adds jobs to task queue
 func (p *WorkSet[T]) queue(jobs []Task[T]) {
-	for _, job := range jobs {
-		p.taskQueue <- job
+	for i, job := range jobs {
+		p.taskQueue <- indexedTask[T]{index: i, task: job}
 	}
 }
 
@@ -69,12 +77,16 @@ func taskHandler[T any](task Task[T], result chan Result[T]) {
 func (p *WorkSet[T]) worker(workerId string) {
 	for {
 		select {
-		case task := <-p.taskQueue:
+		case task, ok := <-p.taskQueue:
+			// the queue is closed once all the results are collected
+			if !ok {
+				return
+			}
 			// single taskResult channel to gather the task taskResult
 			taskResult := make(chan Result[T], 1)
 
 			// asynchronously startWorkers task so that we can see that it does not exceed taskTimeout
-			go taskHandler(task, taskResult)
+			go taskHandler(task.task, taskResult)
 
 			// Execute but queue not exceed taskTimeout
 			var result Result[T]
@@ -85,6 +97,7 @@ func (p *WorkSet[T]) worker(workerId string) {
 			case <-time.After(p.taskTimeout):
 				result = Result[T]{WorkerId: workerId, Error: errors.New("taskTimeout exceeded")}
 			}
+			result.Index = task.index
 
 			p.resultQueue <- result
 
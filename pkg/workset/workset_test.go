@@ -100,3 +100,32 @@ func TestCollect(t *testing.T) {
 		t.Errorf("invalid number of resultQueue: %d", len(results))
 	}
 }
+
+func TestResultIndex(t *testing.T) {
+	size := 50
+
+	var jobs []Task[int]
+	for i := 0; i < size; i++ {
+		n := i
+		jobs = append(jobs, func() (int, error) {
+			if n%5 == 0 {
+				return 0, fmt.Errorf("fail %d", n)
+			}
+			return n, nil
+		})
+	}
+	results := NewWorkSet[int](jobs, 4, time.Second).Collect()
+	seen := map[int]bool{}
+	for _, r := range results {
+		seen[r.Index] = true
+		if r.Error == nil && r.Value != r.Index {
+			t.Errorf("result %d has index %d", r.Value, r.Index)
+		}
+		if r.Error != nil && r.Error.Error() != fmt.Sprintf("fail %d", r.Index) {
+			t.Errorf("error %s has index %d", r.Error, r.Index)
+		}
+	}
+	if len(seen) != size {
+		t.Errorf("expected %d distinct indexes, got %d", size, len(seen))
+	}
+}
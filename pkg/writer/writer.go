@@ -102,3 +102,16 @@ func WriteJson(data interface{}, outFile string, opts ...WriteOption) error {
 	}
 	return nil
 }
+
+// ReadJson reads a json file such as the ones written with WriteJson into v
+func ReadJson(fp string, v any) error {
+	file, err := os.Open(fp)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	return json.NewDecoder(file).Decode(v)
+}